@@ -3,31 +3,110 @@ package cliargs
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"flag"
 	"fmt"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/logging"
+	"github.com/kamil-s-solecki/haze/mutation"
+	"github.com/kamil-s-solecki/haze/template"
+	"github.com/kamil-s-solecki/haze/utils"
 	"os"
 	"regexp"
+	"runtime"
 	"strings"
+	"time"
 )
 
 type StringArrayArg []string
 
+const maxThreads = 1000
+
 type Args struct {
-	Host          string
-	RequestFiles  []string
-	OutputDir     string
-	Proxy         string
-	Cookies       string
-	Headers       StringArrayArg
-	Threads       int
-	MatchCodes    string
-	MatchLengths  string
-	MatchString   string
-	FilterCodes   string
-	FilterLengths string
-	FilterString  string
-	ProbeOnly     bool
-	Har           bool
+	ConfigFile      string
+	Host            string
+	TargetsFile     string
+	RequestFiles    []string
+	OutputDir       string
+	Sarif           string
+	HttpFile        string
+	Webhook         string
+	WebhookEvery    int
+	Proxy           string
+	NoEnvProxy      bool
+	Http1           bool
+	Http2           bool
+	TLSMin          string
+	TLSMax          string
+	Ciphers         string
+	ClientCert      string
+	ClientKey       string
+	Cookies         string
+	BodyFile        string
+	Headers         StringArrayArg
+	Vars            StringArrayArg
+	VarsFile        string
+	EnvVars         bool
+	Methods         string
+	Charsets        string
+	Payloads        StringArrayArg
+	PayloadsOnly    bool
+	Threads         int
+	HostThreads     int
+	Shuffle         bool
+	Seed            int64
+	MaxRequests     int
+	StopOnFirst     bool
+	RetryOnStatus   string
+	Retries         int
+	MaxBody         int
+	TrimBody        int
+	DnsTTL          int
+	MatchCodes      string
+	MatchCodesGiven bool
+	MatchLengths    string
+	MatchWords      string
+	MatchLines      string
+	MatchString     string
+	MatchTime       string
+	MatchTypes      string
+	MatchHeaders    StringArrayArg
+	MatchErrors     bool
+	ErrorSignatures string
+	MatchReflected  bool
+	MatchEncoded    bool
+	FilterCodes     string
+	FilterLengths   string
+	FilterString    string
+	FilterTypes     string
+	AutoCalibrate   bool
+	ProbeOnly       bool
+	DryRun          bool
+	CountOnly       bool
+	Replay          string
+	Har             bool
+	Burp            bool
+	OpenApi         bool
+	Raw             bool
+	Crlf            bool
+	ByteOps         string
+	ByteCount       int
+	GraphqlQuery    bool
+	Identity        bool
+	NoKeepAlive     bool
+	StripHop        bool
+	Verbose         bool
+	ShowErrors      bool
+	Quiet           bool
+	LogLevel        string
+	NoBanner        bool
+	NoColor         bool
+	Columns         string
+	SortBy          string
+	GroupByCode     bool
+	GroupByTag      bool
+	Cluster         bool
+	ClusterDistance int
 }
 
 type Param struct {
@@ -37,28 +116,109 @@ type Param struct {
 
 func ParseArgs() Args {
 	args := Args{}
-	stringVar("GENERAL", &args.Host, Param{Long: "host", Short: "t", Help: "Target host (protocol://hostname:port)"})
+	stringVar("GENERAL", &args.ConfigFile, Param{Long: "config", Help: "JSON file with option values keyed by the long option names,\ne.g. {\"threads\": 20, \"header\": [\"Foo: foo\"]}. Command line options take precedence"})
+	stringVar("GENERAL", &args.Host, Param{Long: "host", Short: "t", Help: "Target host (protocol://hostname:port or unix:/path/to.sock).\nWithout a protocol, https is used for port 443 and http otherwise"})
+	stringVar("GENERAL", &args.TargetsFile, Param{Long: "targets-file", Help: "File with one target host per line. The whole run is repeated for each of them, side by side.\nEmpty lines and lines starting with # are skipped"})
 	boolVar("GENERAL", &args.ProbeOnly, Param{Long: "probe", Short: "p", Help: "Send the probe request only"})
+	boolVar("GENERAL", &args.DryRun, Param{Long: "dry-run", Help: "Print the mutated requests without sending anything"})
+	boolVar("GENERAL", &args.CountOnly, Param{Long: "count-only", Help: "Print the number of mutated requests the run would send and exit"})
+	stringVar("GENERAL", &args.Replay, Param{Long: "replay", Help: "Send the request saved in a report file (e.g. 3.md) once and print the fresh response\nnext to the saved one. No request files are needed"})
 	stringVar("GENERAL", &args.OutputDir, Param{Long: "output", Short: "o", Help: "Directory where the report will be created. (Default: cwd)"})
-	intVar("GENERAL", &args.Threads, Param{Long: "threads", Short: "th", Default: 10, Help: "Number of threads to use for fuzzing"})
-	stringVar("GENERAL", &args.Proxy, Param{Long: "proxy", Short: "x", Help: "Proxy address"})
+	stringVar("GENERAL", &args.Sarif, Param{Long: "sarif", Help: "Also write the reported findings to this SARIF file"})
+	stringVar("GENERAL", &args.HttpFile, Param{Long: "http-file", Help: "Also write the reported requests to this .http file (REST Client, IntelliJ HTTP Client)"})
+	stringVar("GENERAL", &args.Webhook, Param{Long: "webhook", Help: "Webhook url (e.g. Slack) to post the reported findings to"})
+	intVar("GENERAL", &args.WebhookEvery, Param{Long: "webhook-every", Default: 10, Help: "Post to the webhook at most once per this many seconds, batching the findings"})
+	intVar("GENERAL", &args.Threads, Param{Long: "threads", Short: "th", Default: 10, Help: "Number of threads to use for fuzzing. 0 means the number of CPUs"})
+	intVar("GENERAL", &args.HostThreads, Param{Long: "host-threads", Help: "Maximum number of concurrent requests to a single host, e.g. a fragile one. 0 means up to -threads. The hosts of -targets-file are fuzzed side by side, sharing -threads"})
+	boolVar("GENERAL", &args.Shuffle, Param{Long: "shuffle", Help: "Send the mutated requests of each request file in random order"})
+	int64Var("GENERAL", &args.Seed, Param{Long: "seed", Help: "Seed for -shuffle and -bytes, so that a run can be repeated. 0 picks one, which is printed at start"})
+	intVar("GENERAL", &args.MaxRequests, Param{Long: "max-requests", Help: "Stop after sending this many mutated requests. 0 means no limit"})
+	boolVar("GENERAL", &args.StopOnFirst, Param{Long: "stop-on-first", Help: "Stop after the first reported response. Requests already in flight are finished but not reported"})
+	stringVar("GENERAL", &args.RetryOnStatus, Param{Long: "retry-on-status", Help: "Comma-separated list of response codes to resend the request on, e.g. 502,503.\nThe response is reported only if the code persists after -retries attempts"})
+	intVar("GENERAL", &args.Retries, Param{Long: "retries", Default: 2, Help: "How many times to resend a request responding with a -retry-on-status code"})
+	intVar("GENERAL", &args.MaxBody, Param{Long: "max-body", Help: "Read at most this many bytes of each response body. 0 means no limit"})
+	intVar("GENERAL", &args.TrimBody, Param{Long: "trim-body", Default: 1024 * 1024, Help: "Save and print at most this many bytes of each response body. The matchers still see\nthe whole body. 0 keeps the whole body"})
+	intVar("GENERAL", &args.DnsTTL, Param{Long: "dns-ttl", Help: "Reuse resolved addresses for this many seconds instead of resolving on every connection"})
+	stringVar("GENERAL", &args.Proxy, Param{Long: "proxy", Short: "x", Help: "Proxy address. Without it, HTTP_PROXY, HTTPS_PROXY and NO_PROXY are taken from the environment"})
+	boolVar("GENERAL", &args.NoEnvProxy, Param{Long: "no-env-proxy", Help: "Ignore the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables"})
+	boolVar("GENERAL", &args.Http1, Param{Long: "http1", Help: "Force HTTP/1.1"})
+	boolVar("GENERAL", &args.Http2, Param{Long: "http2", Help: "Force HTTP/2. It is negotiated over TLS, so the target should use https"})
+	stringVar("GENERAL", &args.TLSMin, Param{Long: "tls-min", Help: "Minimum TLS version to negotiate: 1.0, 1.1, 1.2 or 1.3"})
+	stringVar("GENERAL", &args.TLSMax, Param{Long: "tls-max", Help: "Maximum TLS version to negotiate: 1.0, 1.1, 1.2 or 1.3"})
+	stringVar("GENERAL", &args.Ciphers, Param{Long: "ciphers", Help: "Comma-separated list of TLS 1.0-1.2 cipher suites to offer, e.g. TLS_RSA_WITH_AES_128_CBC_SHA"})
+	stringVar("GENERAL", &args.ClientCert, Param{Long: "cert", Help: "PEM client certificate for targets requiring mutual TLS. Requires -key"})
+	stringVar("GENERAL", &args.ClientKey, Param{Long: "key", Help: "PEM private key of the client certificate. Requires -cert"})
 	boolVar("GENERAL", &args.Har, Param{Long: "har", Help: "Indicate that the request files are in the har format"})
+	boolVar("GENERAL", &args.Burp, Param{Long: "burp", Help: "Indicate that the request files are Burp Suite XML exports (Save items).\nOnly the items sent to the target (-t) will be fuzzed,\nwithout -t every item is fuzzed against its own host"})
+	boolVar("GENERAL", &args.OpenApi, Param{Long: "openapi", Help: "Indicate that the request files are OpenAPI 3 documents in JSON.\nA request is generated for each operation, with example or placeholder values"})
+	boolVar("GENERAL", &args.Raw, Param{Long: "raw", Help: "Send requests over a raw connection, keeping headers and bodies verbatim\n(e.g. a mismatched Content-Length). The proxy is not used"})
+	boolVar("GENERAL", &args.Crlf, Param{Long: "crlf", Help: "Inject CR/LF sequences into header and cookie values to test for header injection. Implies -raw"})
+	stringVar("GENERAL", &args.ByteOps, Param{Long: "bytes", Help: "Comma-separated list of byte-level mutations of the whole serialized request to also send:\nbitflip, insert, delete, duplicate. Implies -raw"})
+	intVar("GENERAL", &args.ByteCount, Param{Long: "bytes-count", Default: 20, Help: "Number of requests to send for each of the -bytes mutations, at positions picked from -seed"})
+	boolVar("GENERAL", &args.GraphqlQuery, Param{Long: "gql-query", Help: "Fuzz the GraphQL query string as well as its variables"})
+	boolVar("GENERAL", &args.Identity, Param{Long: "identity", Help: "Send 'Accept-Encoding: identity', so that responses come back uncompressed"})
+	boolVar("GENERAL", &args.StripHop, Param{Long: "strip-hop", Help: "Remove hop-by-hop headers (Connection, Keep-Alive, Transfer-Encoding etc.) from the request files.\nIgnored with -raw, which sends them verbatim, e.g. for request smuggling tests"})
+	boolVar("GENERAL", &args.NoKeepAlive, Param{Long: "no-keep-alive", Help: "Send 'Connection: close' and open a fresh connection for every request,\ne.g. for connection-state tests or when pooled connections make the results flaky"})
+	stringVar("GENERAL", &args.BodyFile, Param{Long: "body-file", Help: "File to stream as the body of each request instead of the body from the request files"})
 	stringVar("GENERAL", &args.Cookies, Param{Long: "cookies", Short: "c", Help: "Cookies string. This will replace `Cookie:` header read from request files."})
+	boolVar("GENERAL", &args.Verbose, Param{Long: "verbose", Short: "v", Help: "Print a diff between the original and the mutated request for each crash"})
+	boolVar("GENERAL", &args.ShowErrors, Param{Long: "show-errors", Help: "Print the failed requests with their mutant and the class of the failure: timeout, refused, reset, tls or other"})
+	boolVar("GENERAL", &args.Quiet, Param{Long: "quiet", Short: "q", Help: "Print the crashes only"})
+	stringVar("GENERAL", &args.LogLevel, Param{Long: "log-level", Default: "warn", Help: "Log to stderr at this level: error, warn, info or debug.\ninfo traces throttling and webhook retries, debug also every request sent"})
+	boolVar("GENERAL", &args.NoBanner, Param{Long: "no-banner", Help: "Do not print the banner"})
+	boolVar("GENERAL", &args.NoColor, Param{Long: "no-color", Help: "Do not colorize the output. Colors are also disabled when NO_COLOR is set or stdout is not a terminal"})
+	stringVar("GENERAL", &args.Methods, Param{Long: "methods", Help: "Comma-separated list of methods to fuzz each request with, e.g. GET,POST,PUT"})
+	stringVar("GENERAL", &args.Charsets, Param{Long: "charsets", Help: "Comma-separated list of charsets to also send each mutated text body in, e.g. utf-16,utf-8-overlong.\nAvailable: " + strings.Join(http.Charsets(), ", ")})
+	stringVar("GENERAL", &args.Columns, Param{Long: "columns", Default: "code,len", Help: "Comma-separated list of response columns to print: code, len, words, lines, time (ms)"})
+	stringVar("GENERAL", &args.SortBy, Param{Long: "sort", Help: "Print the reported responses at the end, sorted by: code, len or time.\nNothing is printed while fuzzing"})
+	boolVar("GENERAL", &args.GroupByCode, Param{Long: "group-by-code", Help: "Print the reported responses at the end, grouped by the response code.\nNothing is printed while fuzzing"})
+	boolVar("GENERAL", &args.GroupByTag, Param{Long: "group-by-tag", Help: "Print the reported responses at the end, grouped by the payload tag, e.g. sqli.\nNothing is printed while fuzzing"})
+	boolVar("GENERAL", &args.Cluster, Param{Long: "cluster", Help: "Print the reported responses at the end, one per cluster of similar bodies,\nwith the reports of the rest of the cluster. Nothing is printed while fuzzing"})
+	intVar("GENERAL", &args.ClusterDistance, Param{Long: "cluster-distance", Default: 12, Help: "How many bits of the 64-bit body fingerprints may differ within a -cluster"})
+	stringArrayVar("GENERAL", &args.Payloads, Param{Long: "payloads", Short: "w", Help: "Wordlist file with one payload per line. Each payload replaces the fuzzed values.\nLines starting with # are comments, write \\# for a payload starting with #.\nA `#tag: sqli` line tags the payloads following it, up to the next `#tag:` line.\nYou can provide multiple files: `-w sqli.txt -w xss.txt`."})
+	boolVar("GENERAL", &args.PayloadsOnly, Param{Long: "payloads-only", Help: "Use the payloads from the wordlists only, without the built-in mutations"})
 	stringArrayVar("GENERAL", &args.Headers, Param{Long: "header", Short: "H", Help: "Header string. It overwrites headers that are already present in request files.\nYou can provide multiple values: `-H 'Foo: foo' -H 'Bar: bar'`."})
+	stringArrayVar("GENERAL", &args.Vars, Param{Long: "var", Help: "Value of a {{NAME}} placeholder in the request files, e.g. `-var TOKEN=abc`.\nWrite \\{{NAME}} for a literal {{NAME}}"})
+	stringVar("GENERAL", &args.VarsFile, Param{Long: "vars-file", Help: "CSV file with placeholder names in the header row. The request files are fuzzed once per row"})
+	boolVar("GENERAL", &args.EnvVars, Param{Long: "env-vars", Help: "Take the values of the placeholders without a -var from the environment, e.g. {{API_TOKEN}}"})
 
 	stringVar("MATCHERS", &args.MatchCodes, Param{Long: "mc", Default: "500-599", Help: "Comma-separated list of response codes to report"})
-	stringVar("MATCHERS", &args.MatchLengths, Param{Long: "ml", Help: "Comma-separated list of response lengths to report"})
+	stringVar("MATCHERS", &args.MatchLengths, Param{Long: "ml", Help: "Comma-separated list of response lengths to report. With -mc, both the code and the length have to match"})
+	stringVar("MATCHERS", &args.MatchWords, Param{Long: "mw", Help: "Comma-separated list of response word counts to report"})
+	stringVar("MATCHERS", &args.MatchLines, Param{Long: "mln", Help: "Comma-separated list of response line counts to report"})
 	stringVar("MATCHERS", &args.MatchString, Param{Long: "ms", Help: "A string to match in response"})
+	stringVar("MATCHERS", &args.MatchTime, Param{Long: "mt", Help: "Response time in milliseconds to report: more than (e.g. >5000), less than (<100)\nor a range (1000-3000), e.g. for time-based injections"})
+	stringVar("MATCHERS", &args.MatchTypes, Param{Long: "mct", Help: "Comma-separated list of response content types to report, e.g. application/json. Responses of other types are not reported"})
+	stringArrayVar("MATCHERS", &args.MatchHeaders, Param{Long: "mh", Help: "Response header to report, with an optional substring of its value, e.g. `-mh 'Location: /admin'`.\nA name only, e.g. `-mh X-Debug`, reports any response with the header. You can provide multiple values"})
+	boolVar("MATCHERS", &args.MatchErrors, Param{Long: "me", Help: "Report responses containing known error signatures (SQL errors, stack traces etc.)"})
+	stringVar("MATCHERS", &args.ErrorSignatures, Param{Long: "mef", Help: "File with additional error signature regexes, one per line. Implies -me"})
+	boolVar("MATCHERS", &args.MatchReflected, Param{Long: "mr", Help: "Report responses reflecting the wordlist payload (-w) of the mutant verbatim"})
+	boolVar("MATCHERS", &args.MatchEncoded, Param{Long: "mre", Help: "Report responses reflecting the wordlist payload also HTML or URL encoded. Implies -mr"})
 
 	stringVar("FILTERS", &args.FilterCodes, Param{Long: "fc", Help: "Comma-separated list of response codes to not report"})
 	stringVar("FILTERS", &args.FilterLengths, Param{Long: "fl", Help: "Comma-separated list of response lengths to not report"})
 	stringVar("FILTERS", &args.FilterString, Param{Long: "fs", Help: "A string to filter in response"})
+	stringVar("FILTERS", &args.FilterTypes, Param{Long: "fct", Help: "Comma-separated list of response content types to not report, e.g. text/html"})
+	boolVar("FILTERS", &args.AutoCalibrate, Param{Long: "auto-calibrate", Help: "Do not report responses identical to the probe response: the same code,\nlength and word count within 5%"})
 
 	flag.Usage = printUsage
 
 	flag.Parse()
 	args.RequestFiles = flag.Args()
 
+	if args.ConfigFile != "" {
+		unknown, e := applyConfig(flag.CommandLine, args.ConfigFile)
+		if e != nil {
+			err(e.Error())
+		}
+		for _, key := range unknown {
+			logging.Warnf("unknown option '%v' in %v", key, args.ConfigFile)
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		args.MatchCodesGiven = args.MatchCodesGiven || f.Name == "mc"
+	})
+
 	validate(args)
 
 	fixArgs(&args)
@@ -89,6 +249,18 @@ func intVar(group string, pvar *int, param Param) {
 	}
 }
 
+func int64Var(group string, pvar *int64, param Param) {
+	registerFlag(group, flagName{param.Long, param.Short})
+	deflt := int64(0)
+	if param.Default != nil {
+		deflt = param.Default.(int64)
+	}
+	flag.Int64Var(pvar, param.Long, deflt, param.Help)
+	if param.Short != "" {
+		flag.Int64Var(pvar, param.Short, deflt, "")
+	}
+}
+
 func boolVar(group string, pvar *bool, param Param) {
 	registerFlag(group, flagName{param.Long, param.Short})
 	deflt := false
@@ -119,22 +291,132 @@ func (saa *StringArrayArg) String() string {
 }
 
 func validate(args Args) {
-	validateHost(args.Host)
+	if args.TargetsFile != "" {
+		validateTargetsFile(args)
+	} else if !((args.Har || args.Burp) && args.Host == "") {
+		validateHost(args.Host)
+	}
 	validateProxy(args.Proxy)
-	validateRequests(args.RequestFiles, args.Har)
+	if args.Http1 && args.Http2 {
+		err("Only one of -http1 and -http2 can be used")
+	}
+	validateTLS(args.TLSMin, args.TLSMax, args.Ciphers)
+	if (args.ClientCert == "") != (args.ClientKey == "") {
+		err("The client certificate (-cert) and its key (-key) have to be used together")
+	}
+	if args.Replay != "" {
+		validateFiles([]string{args.Replay})
+	} else {
+		if countTrue(args.Har, args.Burp, args.OpenApi) > 1 {
+			err("Only one of -har, -burp and -openapi can be used")
+		}
+		validateRequests(args.RequestFiles, args.Har || args.OpenApi, args.Burp)
+	}
+	validateRange(args.RetryOnStatus)
+	validateRetries(args.Retries)
 	validateRange(args.MatchCodes)
 	validateRange(args.MatchLengths)
+	validateRange(args.MatchWords)
+	validateRange(args.MatchLines)
+	validateMatchTime(args.MatchTime)
 	validateOutput(args.OutputDir)
+	validateThreads(args.Threads)
+	validateHostThreads(args.HostThreads)
+	validateMaxRequests(args.MaxRequests)
+	validateMaxBody(args.MaxBody)
+	validateTrimBody(args.TrimBody)
+	validateDnsTTL(args.DnsTTL)
+	validateRegexFile(args.ErrorSignatures)
+	validateFiles(args.Payloads)
+	if args.BodyFile != "" {
+		validateFiles([]string{args.BodyFile})
+	}
+	validateHeaders(args.Headers)
+	validateMatchHeaders(args.MatchHeaders)
+	if _, e := template.ParseVars(args.Vars); e != nil {
+		err(e.Error())
+	}
+	if args.VarsFile != "" {
+		validateFiles([]string{args.VarsFile})
+	}
+	validateMethods(args.Methods)
+	validateCharsets(args.Charsets)
+	validateByteOps(args.ByteOps, args.ByteCount)
+	validateColumns(args.Columns)
+	validateSortBy(args.SortBy)
+	if countTrue(args.GroupByCode, args.GroupByTag, args.Cluster) > 1 {
+		err("Only one of -group-by-code, -group-by-tag and -cluster can be used")
+	}
+	if args.ClusterDistance < 0 || args.ClusterDistance > 64 {
+		err(fmt.Sprintf("Invalid cluster distance: %v. It should be between 0 and 64", args.ClusterDistance))
+	}
+	if _, e := logging.ParseLevel(args.LogLevel); e != nil {
+		err(e.Error())
+	}
+	validateWebhook(args.Webhook, args.WebhookEvery)
 }
 
 func validateHost(host string) {
 	if host == "" {
-		err("The target host (-t, -host) is required")
+		err("The target host (-t, -host) or -targets-file is required, unless fuzzing -har or -burp files")
+	}
+	if strings.HasPrefix(host, "unix:/") {
+		return
+	}
+
+	if _, e := http.ParseTarget(host); e != nil {
+		err(e.Error() + "\nThe target host should be in format: protocol://hostname:port or unix:/path/to.sock")
+	}
+}
+
+func validateTargetsFile(args Args) {
+	if args.Host != "" {
+		err("Only one of -t and -targets-file can be used")
+	}
+	targets, e := ReadTargets(args.TargetsFile)
+	if e != nil {
+		err(e.Error())
+	}
+	if len(targets) == 0 {
+		err(fmt.Sprintf("%v has no targets", args.TargetsFile))
+	}
+}
+
+// ReadTargets reads the hosts of a -targets-file, in the normalized form of -t
+func ReadTargets(path string) ([]string, error) {
+	bs, e := os.ReadFile(path)
+	if e != nil {
+		return nil, e
 	}
+	targets := []string{}
+	for i, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		target, e := http.ParseTarget(line)
+		if e != nil {
+			return nil, fmt.Errorf("%v, line %v: %v", path, i+1, e)
+		}
+		targets = append(targets, target.String())
+	}
+	return targets, nil
+}
 
-	r, _ := regexp.Compile("^https?://([-a-zA-Z0-9.]{1,256})(:[0-9]{1,5})?/?$")
-	if !r.MatchString(host) {
-		err("The target host should be in format: protocol://hostname:port")
+func validateTLS(min, max, ciphers string) {
+	minVersion, e := http.ParseTLSVersion(min)
+	if e != nil {
+		err(e.Error())
+	}
+	maxVersion, e := http.ParseTLSVersion(max)
+	if e != nil {
+		err(e.Error())
+	}
+	if minVersion != 0 && maxVersion != 0 && minVersion > maxVersion {
+		err(fmt.Sprintf("Invalid TLS versions: -tls-min %v is greater than -tls-max %v", min, max))
+	}
+	if _, e := http.ParseCipherSuites(ciphers); e != nil {
+		err(e.Error())
 	}
 }
 
@@ -149,17 +431,17 @@ func validateProxy(proxy string) {
 	}
 }
 
-func validateRequests(rqs []string, isHar bool) {
+func validateRequests(rqs []string, isJson, isXml bool) {
 	if len(rqs) == 0 {
 		err("The request file(s) is required")
 	}
 
 	for _, rq := range rqs {
-		validateRequest(rq, isHar)
+		validateRequest(rq, isJson, isXml)
 	}
 }
 
-func validateRequest(request string, isHar bool) {
+func validateRequest(request string, isJson, isXml bool) {
 	fi, e := os.Stat(request)
 	if e != nil {
 		err("Cannot read: " + request)
@@ -168,8 +450,10 @@ func validateRequest(request string, isHar bool) {
 		err(request + " is a directory. Please provide a file")
 	}
 
-	if isHar {
+	if isJson {
 		validateJson(request)
+	} else if isXml {
+		validateXml(request)
 	} else {
 		validateRawRequest(request)
 	}
@@ -195,6 +479,13 @@ func validateJson(request string) {
 	}
 }
 
+func validateXml(request string) {
+	bs, _ := os.ReadFile(request)
+	if e := xml.Unmarshal(bs, new(interface{})); e != nil {
+		err(request + " is not a valid xml")
+	}
+}
+
 func validateRange(val string) {
 	if val == "" {
 		return
@@ -206,6 +497,127 @@ func validateRange(val string) {
 	}
 }
 
+func validateMatchTime(val string) {
+	if val == "" {
+		return
+	}
+
+	r, _ := regexp.Compile("^([<>][0-9]+|[0-9]+-[0-9]+)$")
+	if !r.MatchString(val) {
+		err(fmt.Sprintf("Invalid response time: '%v'. Example correct values: '>5000', '<100', '1000-3000'", val))
+	}
+}
+
+func validateRegexFile(path string) {
+	if path == "" {
+		return
+	}
+
+	if _, e := utils.ReadRegexes(path); e != nil {
+		err(fmt.Sprintf("Invalid regex file %v: %v", path, e))
+	}
+}
+
+func validateFiles(paths []string) {
+	for _, path := range paths {
+		fi, e := os.Stat(path)
+		if e != nil {
+			err("Cannot read: " + path)
+		}
+		if fi.IsDir() {
+			err(path + " is a directory. Please provide a file")
+		}
+	}
+}
+
+func validateHeaders(headers []string) {
+	for _, h := range headers {
+		if e := checkHeader(h); e != nil {
+			err(e.Error())
+		}
+	}
+}
+
+func validateMatchHeaders(headers []string) {
+	for _, h := range headers {
+		name, _, _ := strings.Cut(h, ":")
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(strings.TrimSpace(name), " \t") {
+			err(fmt.Sprintf("Invalid header to match: '%v'. Example correct values: 'Location: /admin', 'X-Debug'", h))
+		}
+	}
+}
+
+func checkHeader(header string) error {
+	name, _, found := strings.Cut(header, ":")
+	if !found || strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("Invalid header: '%v'. Example correct value: 'Foo: foo'", header)
+	}
+	return nil
+}
+
+func validateMethods(methods string) {
+	if methods == "" {
+		return
+	}
+
+	r, _ := regexp.Compile("^[A-Za-z]+(,[A-Za-z]+)*$")
+	if !r.MatchString(methods) {
+		err(fmt.Sprintf("Invalid methods: '%v'. Example correct value: 'GET,POST,PUT'", methods))
+	}
+}
+
+func validateCharsets(charsets string) {
+	if charsets == "" {
+		return
+	}
+	for _, name := range strings.Split(charsets, ",") {
+		if e := http.ValidateCharset(name); e != nil {
+			err(e.Error())
+		}
+	}
+}
+
+func validateByteOps(ops string, count int) {
+	if ops == "" {
+		return
+	}
+	if _, e := mutation.ParseByteOperators(ops); e != nil {
+		err(fmt.Sprintf("Invalid bytes: %v. Available: bitflip, insert, delete, duplicate", e))
+	}
+	if count < 1 {
+		err(fmt.Sprintf("Invalid bytes count: %v. It should be at least 1", count))
+	}
+}
+
+func validateColumns(columns string) {
+	r, _ := regexp.Compile("^(code|len|words|lines|time)(,(code|len|words|lines|time))*$")
+	if !r.MatchString(columns) {
+		err(fmt.Sprintf("Invalid columns: '%v'. Example correct value: 'code,len,words,lines'", columns))
+	}
+}
+
+func validateSortBy(sortBy string) {
+	switch sortBy {
+	case "", "code", "len", "time":
+	default:
+		err(fmt.Sprintf("Invalid sort: '%v'. Possible values: code, len, time", sortBy))
+	}
+}
+
+func validateWebhook(webhook string, every int) {
+	if webhook == "" {
+		return
+	}
+
+	r, _ := regexp.Compile("^https?://[^/]+")
+	if !r.MatchString(webhook) {
+		err("The webhook should be in format: protocol://hostname:port/path")
+	}
+	if every <= 0 {
+		err(fmt.Sprintf("Invalid webhook interval: %v. It has to be positive", every))
+	}
+}
+
 func validateOutput(output string) {
 	if output == "" {
 		return
@@ -220,6 +632,103 @@ func validateOutput(output string) {
 	}
 }
 
+func validateThreads(threads int) {
+	if _, e := resolveThreads(threads); e != nil {
+		err(e.Error())
+	}
+}
+
+func validateHostThreads(threads int) {
+	if threads < 0 {
+		err(fmt.Sprintf("Invalid host threads: %v. It cannot be negative", threads))
+	}
+}
+
+func validateMaxRequests(max int) {
+	if max < 0 {
+		err(fmt.Sprintf("Invalid max requests: %v. It cannot be negative", max))
+	}
+}
+
+func validateRetries(retries int) {
+	if retries < 0 {
+		err(fmt.Sprintf("Invalid retries: %v. It cannot be negative", retries))
+	}
+}
+
+func validateTrimBody(max int) {
+	if max < 0 {
+		err(fmt.Sprintf("Invalid trim body: %v. It cannot be negative", max))
+	}
+}
+
+func validateMaxBody(max int) {
+	if max < 0 {
+		err(fmt.Sprintf("Invalid max body: %v. It cannot be negative", max))
+	}
+}
+
+func validateDnsTTL(ttl int) {
+	if ttl < 0 {
+		err(fmt.Sprintf("Invalid DNS TTL: %v. It cannot be negative", ttl))
+	}
+}
+
+func resolveThreads(threads int) (int, error) {
+	switch {
+	case threads < 0:
+		return 0, fmt.Errorf("Invalid number of threads: %v. It cannot be negative", threads)
+	case threads == 0:
+		return runtime.NumCPU(), nil
+	case threads > maxThreads:
+		return maxThreads, nil
+	default:
+		return threads, nil
+	}
+}
+
+// secretFlags take values which must not end up in a shared report, see RedactedCommand
+var secretFlags = map[string]bool{"cookies": true, "c": true, "header": true, "H": true, "var": true, "webhook": true, "proxy": true, "x": true}
+
+// RedactedCommand is the command line with the values of the secret flags masked. The names of
+// the headers and variables are kept, e.g. -H 'Authorization: ***'.
+func RedactedCommand(argv []string) string {
+	result := []string{}
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		name, val, inline := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !secretFlags[name] {
+			result = append(result, arg)
+			continue
+		}
+		if !inline {
+			if i+1 == len(argv) {
+				result = append(result, arg)
+				continue
+			}
+			i++
+			val = argv[i]
+		}
+		masked := "***"
+		if sep := strings.IndexAny(val, ":="); sep > 0 && (name == "header" || name == "H" || name == "var") {
+			masked = val[:sep+1] + "***"
+		}
+		dashes := arg[:len(arg)-len(strings.TrimLeft(arg, "-"))]
+		result = append(result, dashes+name, "'"+masked+"'")
+	}
+	return strings.Join(result, " ")
+}
+
+func countTrue(flags ...bool) int {
+	count := 0
+	for _, f := range flags {
+		if f {
+			count++
+		}
+	}
+	return count
+}
+
 func err(msg string) {
 	fmt.Println(msg)
 	flag.Usage()
@@ -227,7 +736,25 @@ func err(msg string) {
 }
 
 func fixArgs(args *Args) {
-	if args.Host[len(args.Host)-1:] == "/" {
-		args.Host = args.Host[:len(args.Host)-1]
+	threads, _ := resolveThreads(args.Threads)
+	if args.Threads > maxThreads {
+		logging.Warnf("%v threads is too many, using %v", args.Threads, threads)
+	}
+	args.Threads = threads
+
+	if args.Crlf || args.ByteOps != "" {
+		args.Raw = true
+	}
+
+	if (args.Shuffle || args.ByteOps != "") && args.Seed == 0 {
+		args.Seed = time.Now().UnixNano()
+	}
+
+	if target, e := http.ParseTarget(args.Host); e == nil {
+		if implied := target.ImpliedScheme(); implied != "" && implied != target.Scheme {
+			logging.Warnf("port %v is meant for %v, not %v, using %v://", target.Port, implied, target.Scheme, implied)
+			target.Scheme = implied
+		}
+		args.Host = target.String()
 	}
 }
@@ -0,0 +1,58 @@
+package cliargs
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kamil-s-solecki/haze/utils"
+	"os"
+)
+
+// applyConfig sets the flags from a json file keyed by long flag names.
+// Flags already given on the command line win over the file.
+func applyConfig(fs *flag.FlagSet, path string) (unknown []string, e error) {
+	bs, e := os.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	config := map[string]interface{}{}
+	if e := json.Unmarshal(bs, &config); e != nil {
+		return nil, fmt.Errorf("Invalid config file %v: %v", path, e)
+	}
+
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	for _, key := range utils.SortedKeys(config) {
+		if fs.Lookup(key) == nil {
+			unknown = append(unknown, key)
+			continue
+		}
+		if given[key] || given[shortName(key)] {
+			continue
+		}
+		values, ok := config[key].([]interface{})
+		if !ok {
+			values = []interface{}{config[key]}
+		}
+		for _, v := range values {
+			if e := fs.Set(key, fmt.Sprint(v)); e != nil {
+				return nil, fmt.Errorf("Invalid value for '%v' in %v: %v", key, path, e)
+			}
+		}
+	}
+	return unknown, nil
+}
+
+func shortName(long string) string {
+	for _, g := range groups {
+		for _, fn := range g.flagNames {
+			if fn.long == long {
+				return fn.short
+			}
+		}
+	}
+	return ""
+}
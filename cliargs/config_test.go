@@ -0,0 +1,77 @@
+package cliargs
+
+import (
+	"flag"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func configFlagSet(args *Args) *flag.FlagSet {
+	fs := flag.NewFlagSet("haze", flag.ContinueOnError)
+	fs.StringVar(&args.MatchCodes, "mc", "500-599", "")
+	fs.StringVar(&args.MatchLengths, "ml", "", "")
+	fs.IntVar(&args.Threads, "threads", 10, "")
+	fs.BoolVar(&args.Verbose, "verbose", false, "")
+	fs.Var(&args.Headers, "header", "")
+	return fs
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "haze.json")
+	os.WriteFile(path, []byte(content), 0644)
+	return path
+}
+
+func TestConfigSetsFields(t *testing.T) {
+	args := Args{}
+	fs := configFlagSet(&args)
+	fs.Parse([]string{})
+	path := writeConfig(t, `{"ml": "10-20", "threads": 20, "verbose": true, "header": ["Foo: foo", "Bar: bar"]}`)
+
+	unknown, err := applyConfig(fs, path)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, unknown, 0)
+	testutils.AssertEquals(t, args.MatchLengths, "10-20")
+	testutils.AssertEquals(t, args.Threads, 20)
+	testutils.AssertTrue(t, args.Verbose)
+	testutils.AssertLen(t, args.Headers, 2)
+}
+
+func TestFlagOverridesConfig(t *testing.T) {
+	args := Args{}
+	fs := configFlagSet(&args)
+	fs.Parse([]string{"-mc", "200"})
+	path := writeConfig(t, `{"mc": "404", "ml": "5"}`)
+
+	_, err := applyConfig(fs, path)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, args.MatchCodes, "200")
+	testutils.AssertEquals(t, args.MatchLengths, "5")
+}
+
+func TestConfigReportsUnknownKeys(t *testing.T) {
+	args := Args{}
+	fs := configFlagSet(&args)
+	fs.Parse([]string{})
+
+	unknown, err := applyConfig(fs, writeConfig(t, `{"thredas": 3, "mc": "200"}`))
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, unknown, 1)
+	testutils.AssertEquals(t, unknown[0], "thredas")
+	testutils.AssertEquals(t, args.MatchCodes, "200")
+}
+
+func TestConfigRejectsInvalidValue(t *testing.T) {
+	args := Args{}
+	fs := configFlagSet(&args)
+	fs.Parse([]string{})
+
+	_, err := applyConfig(fs, writeConfig(t, `{"threads": "many"}`))
+
+	testutils.AssertTrue(t, err != nil)
+}
@@ -3,6 +3,7 @@ package cliargs
 import (
 	"flag"
 	"fmt"
+	"github.com/kamil-s-solecki/haze/utils"
 	"strings"
 )
 
@@ -40,6 +41,7 @@ func printUsage() {
 		"File(s) containing the raw http request(s)",
 		"in case of .har files pass the -har flag",
 		"only the har entries which match the target (-t) value will be fuzzed",
+		"without -t, all the http(s) har entries are fuzzed, each against its own host",
 	})
 	for _, g := range groups {
 		fmt.Printf("\n%v:\n", g.name)
@@ -55,7 +57,7 @@ func printFlag(fn flagName, usage, defValue string) {
 	if fn.short != "" {
 		ln += ", -" + fn.short
 	}
-	ln += strings.Repeat(" ", keyLen-len(ln))
+	ln += padding(ln)
 	usageLines := strings.Split(usage, "\n")
 	ln += usageLines[0]
 	for _, ul := range usageLines[1:] {
@@ -69,10 +71,15 @@ func printFlag(fn flagName, usage, defValue string) {
 
 func printArg(name string, usage []string) {
 	ln := "  " + name
-	ln += strings.Repeat(" ", keyLen-len(ln))
+	ln += padding(ln)
 	ln += usage[0]
 	for i := 1; i < len(usage); i++ {
 		ln += "\n" + strings.Repeat(" ", keyLen) + usage[i]
 	}
 	fmt.Println(ln)
 }
+
+// padding keeps at least a space between a long flag and its help
+func padding(key string) string {
+	return " " + utils.Padding(key, keyLen-1)
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type burpItems struct {
+	Items []burpItem `xml:"item"`
+}
+
+type burpItem struct {
+	Host     string      `xml:"host"`
+	Port     string      `xml:"port"`
+	Protocol string      `xml:"protocol"`
+	Request  burpRequest `xml:"request"`
+}
+
+type burpRequest struct {
+	Base64 bool   `xml:"base64,attr"`
+	Raw    string `xml:",chardata"`
+}
+
+// ParseBurp reads the requests of a Burp Suite XML export ("Save items"), each with the host it
+// was sent to. With a target, only the items sent to it are returned.
+func ParseBurp(data []byte, target string) ([]Request, error) {
+	var items burpItems
+	if err := xml.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("Invalid Burp export: %v", err)
+	}
+	var want Target
+	if target != "" {
+		var err error
+		if want, err = ParseTarget(target); err != nil {
+			return nil, err
+		}
+	}
+
+	result := []Request{}
+	for i, item := range items.Items {
+		itemTarget, err := ParseTarget(item.Protocol + "://" + item.Host + ":" + item.Port)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Burp export: item %v: %v", i+1, err)
+		}
+		if target != "" && !sameTarget(itemTarget, want) {
+			continue
+		}
+		raw := []byte(item.Request.Raw)
+		if item.Request.Base64 {
+			raw, err = base64.StdEncoding.DecodeString(strings.TrimSpace(item.Request.Raw))
+			if err != nil {
+				return nil, fmt.Errorf("Invalid Burp export: item %v: %v", i+1, err)
+			}
+		}
+		rq := Parse(raw)
+		rq.Target = itemTarget.String()
+		result = append(result, rq)
+	}
+	return result, nil
+}
+
+func sameTarget(a, b Target) bool {
+	return a.Scheme == b.Scheme && strings.EqualFold(a.Addr(), b.Addr())
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseTwoItemsFromBurp(t *testing.T) {
+	data, _ := os.ReadFile("../var/burp/two.xml")
+
+	got, err := ParseBurp(data, "http://localhost:9090")
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Method, "GET")
+	testutils.AssertEquals(t, got[0].RequestUri, "/api/users?id=1")
+	testutils.AssertMapEquals(t, got[0].Cookies, map[string]string{"session": "abc"})
+	testutils.AssertEquals(t, got[0].Headers["User-Agent"], "Fooagent")
+	testutils.AssertEquals(t, got[1].Method, "POST")
+	testutils.AssertTrue(t, got[1].HasJsonBody())
+	testutils.AssertByteEquals(t, got[1].Body, []byte(`{"name": "foo"}`))
+}
+
+func TestParseBurpSkipsItemsForOtherTargets(t *testing.T) {
+	data, _ := os.ReadFile("../var/burp/two.xml")
+
+	for _, target := range []string{"https://localhost:9090", "http://localhost:9091", "http://example.com:9090"} {
+		got, err := ParseBurp(data, target)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertLen(t, got, 0)
+	}
+}
+
+func TestParseBurpPlainTextRequest(t *testing.T) {
+	data := []byte(`<items><item><host>example.com</host><port>443</port><protocol>https</protocol>` +
+		`<request base64="false"><![CDATA[GET /?a=1 HTTP/1.1` + "\nHost: example.com\n\n" + `]]></request></item></items>`)
+
+	got, err := ParseBurp(data, "https://example.com")
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, got[0].Query, "a=1")
+}
+
+func TestParseBurpRejectsInvalidExports(t *testing.T) {
+	_, err := ParseBurp([]byte("{}"), "http://localhost")
+	testutils.AssertTrue(t, err != nil)
+
+	_, err = ParseBurp([]byte(`<items><item><host>localhost</host><port>80</port><protocol>http</protocol>`+
+		`<request base64="true">not base64!</request></item></items>`), "http://localhost")
+	testutils.AssertTrue(t, err != nil && strings.Contains(err.Error(), "item 1"))
+}
+
+func TestParseBurpWithoutTargetKeepsEveryHost(t *testing.T) {
+	data := []byte(`<items>` +
+		`<item><host>a.test</host><port>443</port><protocol>https</protocol><request base64="false"><![CDATA[GET /a HTTP/1.1` + "\nHost: a.test\n\n" + `]]></request></item>` +
+		`<item><host>b.test</host><port>8080</port><protocol>http</protocol><request base64="false"><![CDATA[GET /b HTTP/1.1` + "\nHost: b.test\n\n" + `]]></request></item>` +
+		`</items>`)
+
+	got, err := ParseBurp(data, "")
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Target, "https://a.test:443")
+	testutils.AssertEquals(t, got[1].Target, "http://b.test:8080")
+}
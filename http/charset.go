@@ -0,0 +1,103 @@
+package http
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type charset struct {
+	label  string
+	encode func(string) []byte
+}
+
+// charsets are the encodings a textual body can be re-encoded to. The overlong UTF-8 one writes
+// the ASCII punctuation as invalid two-byte sequences, which some WAFs decode and others skip.
+var charsets = map[string]charset{
+	"utf-16":         {"utf-16", func(s string) []byte { return append([]byte{0xfe, 0xff}, encodeUtf16(s, binary.BigEndian)...) }},
+	"utf-16be":       {"utf-16be", func(s string) []byte { return encodeUtf16(s, binary.BigEndian) }},
+	"utf-16le":       {"utf-16le", func(s string) []byte { return encodeUtf16(s, binary.LittleEndian) }},
+	"utf-32":         {"utf-32", func(s string) []byte { return append([]byte{0, 0, 0xfe, 0xff}, encodeUtf32(s, binary.BigEndian)...) }},
+	"utf-32be":       {"utf-32be", func(s string) []byte { return encodeUtf32(s, binary.BigEndian) }},
+	"utf-32le":       {"utf-32le", func(s string) []byte { return encodeUtf32(s, binary.LittleEndian) }},
+	"utf-8-overlong": {"utf-8", encodeOverlongUtf8},
+}
+
+// Charsets lists the names accepted by WithCharset
+func Charsets() []string {
+	names := make([]string, 0, len(charsets))
+	for name := range charsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func ValidateCharset(name string) error {
+	if _, ok := charsets[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("Unsupported charset '%v', expected one of: %v", name, strings.Join(Charsets(), ", "))
+	}
+	return nil
+}
+
+var textualContentType = regexp.MustCompile(`^(text/[-+.\w]+|application/([-.\w]+\+)?(json|xml)|application/x-www-form-urlencoded)\s*(;|$)`)
+
+// HasTextBody tells whether the body is text which can be re-encoded, judging by the Content-Type
+func (r Request) HasTextBody() bool {
+	ct, ok := r.Header("Content-Type")
+	return ok && len(r.Body) > 0 && !r.streamsBody() && textualContentType.MatchString(strings.ToLower(ct))
+}
+
+var charsetParam = regexp.MustCompile(`(?i)\s*;\s*charset\s*=\s*("[^"]*"|[^;]*)`)
+
+// WithCharset re-encodes the UTF-8 body to the charset and declares it in the Content-Type.
+// Invalid UTF-8 sequences become U+FFFD. See HasTextBody for which bodies make sense to re-encode.
+func (r Request) WithCharset(name string) (Request, error) {
+	cs, ok := charsets[strings.ToLower(name)]
+	if !ok {
+		return r, ValidateCharset(name)
+	}
+	result := r.withFixedBody(cs.encode(string(r.Body)))
+	if key, ok := headerKey(result.Headers, "Content-Type"); ok {
+		result.Headers[key] = charsetParam.ReplaceAllString(r.Headers[key], "") + "; charset=" + cs.label
+	}
+	return result, nil
+}
+
+func encodeUtf16(s string, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(s))
+	result := make([]byte, 2*len(units))
+	for i, unit := range units {
+		order.PutUint16(result[2*i:], unit)
+	}
+	return result
+}
+
+func encodeUtf32(s string, order binary.ByteOrder) []byte {
+	runes := []rune(s)
+	result := make([]byte, 4*len(runes))
+	for i, r := range runes {
+		order.PutUint32(result[4*i:], uint32(r))
+	}
+	return result
+}
+
+func encodeOverlongUtf8(s string) []byte {
+	result := []byte{}
+	for _, r := range s {
+		if r >= utf8.RuneSelf || isAlphanumeric(r) {
+			result = utf8.AppendRune(result, r)
+			continue
+		}
+		result = append(result, 0xc0|byte(r>>6), 0x80|byte(r&0x3f))
+	}
+	return result
+}
+
+func isAlphanumeric(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func TestShouldReencodeBodyToUtf16(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/json\r\nContent-Length: 8\r\n\r\n{\"a\":\"é\"}"))
+
+	got, err := rq.WithCharset("UTF-16LE")
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertByteEquals(t, got.Body, []byte{'{', 0, '"', 0, 'a', 0, '"', 0, ':', 0, '"', 0, 0xe9, 0, '"', 0, '}', 0})
+	testutils.AssertEquals(t, got.Headers["Content-Type"], "application/json; charset=utf-16le")
+	testutils.AssertEquals(t, got.Headers["Content-Length"], "18")
+	testutils.AssertEquals(t, rq.Headers["Content-Type"], "application/json")
+}
+
+func TestShouldWriteByteOrderMarks(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Type: text/plain\r\n\r\nA"))
+
+	utf16, _ := rq.WithCharset("utf-16")
+	utf32, _ := rq.WithCharset("utf-32")
+
+	testutils.AssertByteEquals(t, utf16.Body, []byte{0xfe, 0xff, 0, 'A'})
+	testutils.AssertByteEquals(t, utf32.Body, []byte{0, 0, 0xfe, 0xff, 0, 0, 0, 'A'})
+}
+
+func TestShouldReplaceDeclaredCharset(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\ncontent-type: application/x-www-form-urlencoded; Charset=\"UTF-8\"; foo=bar\r\n\r\na=1"))
+
+	got, _ := rq.WithCharset("utf-16be")
+
+	testutils.AssertEquals(t, got.Headers["content-type"], "application/x-www-form-urlencoded; foo=bar; charset=utf-16be")
+	testutils.AssertByteEquals(t, got.Body, []byte{0, 'a', 0, '=', 0, '1'})
+}
+
+func TestShouldWriteOverlongPunctuation(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Type: text/xml\r\n\r\n<a>é"))
+
+	got, _ := rq.WithCharset("utf-8-overlong")
+
+	testutils.AssertByteEquals(t, got.Body, []byte{0xc0, 0xbc, 'a', 0xc0, 0xbe, 0xc3, 0xa9})
+	testutils.AssertEquals(t, got.Headers["Content-Type"], "text/xml; charset=utf-8")
+}
+
+func TestShouldRejectUnsupportedCharset(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Type: text/plain\r\n\r\nA"))
+
+	got, err := rq.WithCharset("ebcdic")
+
+	testutils.AssertTrue(t, err != nil)
+	testutils.AssertTrue(t, ValidateCharset("ebcdic") != nil)
+	testutils.AssertTrue(t, ValidateCharset("UTF-16") == nil)
+	testutils.AssertByteEquals(t, got.Body, []byte("A"))
+}
+
+func TestHasTextBody(t *testing.T) {
+	cases := []struct {
+		contentType, body string
+		text              bool
+	}{
+		{"application/json", "{}", true},
+		{"application/vnd.api+json; charset=utf-8", "{}", true},
+		{"text/html", "<p>", true},
+		{"application/soap+xml", "<a/>", true},
+		{"application/x-www-form-urlencoded", "a=1", true},
+		{"application/json", "", false},
+		{"application/octet-stream", "abc", false},
+		{"multipart/form-data; boundary=x", "--x", false},
+		{"", "abc", false},
+	}
+
+	for _, c := range cases {
+		raw := "POST / HTTP/1.1\r\nHost: localhost\r\n"
+		if c.contentType != "" {
+			raw += "Content-Type: " + c.contentType + "\r\n"
+		}
+		rq := Parse([]byte(raw + "\r\n" + c.body))
+
+		testutils.AssertEquals(t, rq.HasTextBody(), c.text)
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func countLookups(t *testing.T) *int {
+	lookups := 0
+	orig := lookupHost
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		return []string{"127.0.0.1"}, nil
+	}
+	t.Cleanup(func() { lookupHost = orig })
+	return &lookups
+}
+
+func TestShouldResolveOnceForManyConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	defer func(tr http.RoundTripper) {
+		http.DefaultTransport = tr
+		dialContext = (&net.Dialer{}).DialContext
+	}(http.DefaultTransport)
+	lookups := countLookups(t)
+	host := strings.Replace(srv.URL, "127.0.0.1", "target.test", 1)
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: target.test\r\n\r\n"))
+
+	SetupTransport(TransportOptions{Host: host, DnsCacheTTL: time.Minute})
+	for i := 0; i < 5; i++ {
+		res, err := rq.SendRaw(host)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, 200)
+	}
+	_, err := rq.Send(host)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, *lookups, 1)
+}
+
+func TestShouldResolveAgainAfterTTL(t *testing.T) {
+	lookups := countLookups(t)
+	cache := newDnsCache(time.Nanosecond)
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		cache.resolve(context.Background(), "target.test")
+	}
+
+	testutils.AssertEquals(t, *lookups, 3)
+}
+
+func TestShouldNotResolveIpAddresses(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	lookups := countLookups(t)
+
+	conn, err := newDnsCache(time.Minute).dialContext(context.Background(), "tcp", l.Addr().String())
+
+	testutils.AssertTrue(t, err == nil)
+	conn.Close()
+	testutils.AssertEquals(t, *lookups, 0)
+}
+
+func TestSlowLookupDoesNotBlockOtherHosts(t *testing.T) {
+	release := make(chan struct{})
+	orig := lookupHost
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		if host == "slow.test" {
+			<-release
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+	defer func() { lookupHost = orig }()
+	cache := newDnsCache(time.Minute)
+	go cache.resolve(context.Background(), "slow.test")
+	time.Sleep(10 * time.Millisecond)
+
+	addrs, err := cache.resolve(context.Background(), "fast.test")
+	close(release)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, addrs, 1)
+}
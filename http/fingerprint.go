@@ -0,0 +1,54 @@
+package http
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math/bits"
+	"unicode"
+)
+
+// Fingerprint is a simhash of the body: bodies differing only by a few tokens, like an echoed
+// payload or a request id, get fingerprints differing only by a few bits. See Distance.
+func (res Response) Fingerprint() uint64 {
+	tokens := bytes.FieldsFunc(bytes.ToLower(res.Body()), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(tokens) == 0 {
+		return 0
+	}
+
+	// both the tokens and the pairs of adjacent ones are features, so that the order counts too
+	weights := [64]int{}
+	for i := range tokens {
+		addFeature(&weights, tokens[i])
+		if i > 0 {
+			addFeature(&weights, bytes.Join([][]byte{tokens[i-1], tokens[i]}, []byte(" ")))
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+func addFeature(weights *[64]int, feature []byte) {
+	h := fnv.New64a()
+	h.Write(feature)
+	sum := h.Sum64()
+	for bit := range weights {
+		if sum&(1<<bit) != 0 {
+			weights[bit]++
+		} else {
+			weights[bit]--
+		}
+	}
+}
+
+// Distance is the number of bits two fingerprints differ by, from 0 for the same bodies to 64
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func responseWithBody(body string) Response {
+	return Response{Code: 500, Raw: []byte("HTTP/1.1 500 Internal Server Error\r\nContent-Type: text/html\r\n\r\n" + body)}
+}
+
+func TestFingerprintIsStable(t *testing.T) {
+	body := "<html><body><h1>Internal Server Error</h1></body></html>"
+
+	testutils.AssertEquals(t, responseWithBody(body).Fingerprint(), responseWithBody(body).Fingerprint())
+	testutils.AssertEquals(t, responseWithBody(body).Fingerprint(), uint64(0xc88c87d5b54d1d90))
+	testutils.AssertEquals(t, responseWithBody("").Fingerprint(), uint64(0))
+}
+
+func TestNearDuplicatesHaveCloseFingerprints(t *testing.T) {
+	a := responseWithBody("<html><body><h1>Internal Server Error</h1><p>Error near 'abc'' at line 1 of your SQL syntax. Request id 12345</p></body></html>")
+	b := responseWithBody("<html><body><h1>Internal Server Error</h1><p>Error near 'xyz'' at line 1 of your SQL syntax. Request id 99881</p></body></html>")
+	c := responseWithBody("<html><body><h1>Not Found</h1><p>The page you requested could not be found on this server, sorry.</p></body></html>")
+
+	near := Distance(a.Fingerprint(), b.Fingerprint())
+	far := Distance(a.Fingerprint(), c.Fingerprint())
+
+	testutils.AssertTrue(t, near <= 12)
+	testutils.AssertTrue(t, far > 20)
+	testutils.AssertEquals(t, Distance(a.Fingerprint(), a.Fingerprint()), 0)
+}
+
+func TestFingerprintIgnoresCaseAndPunctuation(t *testing.T) {
+	a := responseWithBody(`{"error": "Invalid Token"}`)
+	b := responseWithBody("error: invalid token")
+
+	testutils.AssertEquals(t, a.Fingerprint(), b.Fingerprint())
+}
@@ -6,13 +6,18 @@ import (
 	"strings"
 )
 
+// ParseHar returns the requests of the entries which urls start with the target, or of all
+// the entries when the target is empty. Entries other than http(s), e.g. data: urls, are skipped.
 func ParseHar(data []byte, target string) []Request {
 	har := unmarshalData(data)
 
 	result := []Request{}
 	forEachEntry(har, func(entry map[string]interface{}) {
-		entryUrl := extractHarUrl(entry).String()
-		if strings.HasPrefix(entryUrl, target) {
+		entryUrl := extractHarUrl(entry)
+		if entryUrl == nil || (entryUrl.Scheme != "http" && entryUrl.Scheme != "https") {
+			return
+		}
+		if strings.HasPrefix(entryUrl.String(), target) {
 			result = append(result, entryToRequest(entry))
 		}
 	})
@@ -46,9 +51,15 @@ func entryToRequest(entry map[string]interface{}) Request {
 		Cookies:    extractHarCookies(entry),
 		Headers:    extractHarHeaders(entry),
 		Body:       extractHarBody(entry),
+		Target:     extractHarTarget(entry),
 	}
 }
 
+func extractHarTarget(entry map[string]interface{}) string {
+	url := extractHarUrl(entry)
+	return url.Scheme + "://" + url.Host
+}
+
 func extractHarMethod(entry map[string]interface{}) string {
 	return entry["method"].(string)
 }
@@ -69,7 +80,10 @@ func extractHarQuery(entry map[string]interface{}) string {
 }
 
 func extractHarUrl(entry map[string]interface{}) *url.URL {
-	url, _ := url.Parse(entry["url"].(string))
+	url, err := url.Parse(entry["url"].(string))
+	if err != nil {
+		return nil
+	}
 	return url
 }
 
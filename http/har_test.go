@@ -54,3 +54,28 @@ func TestFilterHarRequestsBasedOnHost(t *testing.T) {
 	testutils.AssertLen(t, got, 1)
 	testutils.AssertEquals(t, got[0].Path, "/correct")
 }
+
+func TestParseAllFuzzableEntriesFromHarWithoutTarget(t *testing.T) {
+	har := readHar("../var/hars/session.har")
+
+	got := ParseHar(har, "")
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Method, "GET")
+	testutils.AssertEquals(t, got[0].Target, "http://localhost:9090")
+	testutils.AssertEquals(t, got[0].Query, "page=home")
+	testutils.AssertMapEquals(t, got[0].Cookies, map[string]string{"sid": "abc"})
+	testutils.AssertEquals(t, got[1].Method, "POST")
+	testutils.AssertEquals(t, got[1].Target, "https://api.example.com:8443")
+	testutils.AssertEquals(t, got[1].Path, "/v1/items")
+	testutils.AssertByteEquals(t, got[1].Body, []byte(`{"name": "foo"}`))
+}
+
+func TestParseHarSkipsNonHttpEntries(t *testing.T) {
+	har := readHar("../var/hars/session.har")
+
+	got := ParseHar(har, "http://localhost:9090")
+
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, got[0].Path, "/index.php")
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"sync"
+)
+
+// MaxPerHost caps the requests in flight to a single host, across all the threads. 0 means no cap.
+var MaxPerHost int
+
+type hostLimiter struct {
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+var globalHostLimiter = &hostLimiter{slots: map[string]chan struct{}{}}
+
+// acquire waits for a free slot of the host and returns the func giving it back
+func (l *hostLimiter) acquire(host string) func() {
+	if MaxPerHost <= 0 {
+		return func() {}
+	}
+	if target, err := ParseTarget(host); err == nil {
+		host = target.String()
+	}
+	l.mu.Lock()
+	slots, ok := l.slots[host]
+	if !ok {
+		slots = make(chan struct{}, MaxPerHost)
+		l.slots[host] = slots
+	}
+	l.mu.Unlock()
+
+	slots <- struct{}{}
+	return func() { <-slots }
+}
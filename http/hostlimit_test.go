@@ -0,0 +1,55 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func concurrencyServer(peak *int64) *httptest.Server {
+	var inFlight int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			max := atomic.LoadInt64(peak)
+			if n <= max || atomic.CompareAndSwapInt64(peak, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}))
+}
+
+func TestNoHostSeesMoreThanItsConcurrentRequests(t *testing.T) {
+	var peakA, peakB int64
+	a, b := concurrencyServer(&peakA), concurrencyServer(&peakB)
+	defer a.Close()
+	defer b.Close()
+	defer func(max int) { MaxPerHost = max }(MaxPerHost)
+	MaxPerHost = 1
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		for _, host := range []string{a.URL, b.URL} {
+			wg.Add(1)
+			go func(host string, raw bool) {
+				defer wg.Done()
+				if raw {
+					rq.SendRaw(host)
+				} else {
+					rq.Send(host)
+				}
+			}(host, i%2 == 0)
+		}
+	}
+	wg.Wait()
+
+	testutils.AssertEquals(t, atomic.LoadInt64(&peakA), int64(1))
+	testutils.AssertEquals(t, atomic.LoadInt64(&peakB), int64(1))
+}
@@ -2,14 +2,25 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"github.com/kamil-s-solecki/haze/utils"
+	"html"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httputil"
+	"net/textproto"
 	"net/url"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Request struct {
@@ -21,64 +32,182 @@ type Request struct {
 	Headers         map[string]string
 	Cookies         map[string]string
 	Body            []byte
+	BodyFile        string
+	// Target is the protocol://hostname:port the request was captured for, if its source says so
+	Target string
+	// Wire, if set, is sent over a raw connection instead of the fields above, see WireBytes
+	Wire []byte
+	// headerLines are the header lines as parsed, in their order and with duplicates
+	headerLines []headerLine
+}
+
+type headerLine struct {
+	name, raw string
 }
 
 type Response struct {
-	Code   int
-	Length int64
-	Raw    []byte
+	Code      int
+	Length    int64
+	Raw       []byte
+	Headers   map[string][]string
+	Truncated bool
+	// Duration is the time from sending the request to reading the whole response
+	Duration time.Duration
+	// bodyAt is where the body starts in Raw, 0 if not known yet. See Cached.
+	bodyAt int
 }
 
-func SetupTransport(proxyUrl string) {
+var MaxBodyBytes int64
+
+const unixPrefix = "unix:"
+
+type Protocol int
+
+const (
+	Auto Protocol = iota
+	Http1
+	Http2
+)
+
+type TransportOptions struct {
+	Host, Proxy    string
+	NoEnvProxy     bool
+	Protocol       Protocol
+	MinTLS, MaxTLS uint16
+	CipherSuites   []uint16
+	// ClientCert and ClientKey are PEM files presented to servers requiring mutual TLS
+	ClientCert, ClientKey string
+	// DnsCacheTTL is how long resolved addresses are reused. 0 resolves on every connection
+	DnsCacheTTL time.Duration
+	NoKeepAlive bool
+}
+
+func SetupTransport(opts TransportOptions) error {
+	conf, err := newTLSConfig(opts)
+	if err != nil {
+		return err
+	}
+	tlsConfig = conf
+	dialContext = (&net.Dialer{}).DialContext
+	if opts.DnsCacheTTL > 0 {
+		dialContext = newDnsCache(opts.DnsCacheTTL).dialContext
+	}
 	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig:       tlsConfig,
+		DialContext:           dialContext,
+		ExpectContinueTimeout: time.Second,
+		DisableKeepAlives:     opts.NoKeepAlive,
 	}
-	if proxyUrl != "" {
-		purl, _ := url.Parse(proxyUrl)
+	if opts.Proxy != "" {
+		purl, _ := url.Parse(opts.Proxy)
 		tr.Proxy = http.ProxyURL(purl)
+	} else if !opts.NoEnvProxy {
+		tr.Proxy = envProxy()
+	}
+	switch opts.Protocol {
+	case Http1:
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case Http2:
+		tr.ForceAttemptHTTP2 = true
+	}
+	if socket, ok := unixSocket(opts.Host); ok {
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
 	}
 	http.DefaultTransport = tr
+	return nil
+}
+
+func unixSocket(host string) (string, bool) {
+	if !strings.HasPrefix(host, unixPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(host, unixPrefix), true
+}
+
+// normalizeHost brackets a bare IPv6 literal, e.g. http://::1 becomes http://[::1]
+func normalizeHost(host string) string {
+	if _, ok := unixSocket(host); ok {
+		return host
+	}
+	scheme, authority, found := strings.Cut(host, "://")
+	if !found {
+		return host
+	}
+	authority = strings.TrimSuffix(authority, "/")
+	if ip := net.ParseIP(authority); ip != nil && strings.Contains(authority, ":") {
+		authority = "[" + authority + "]"
+	}
+	return scheme + "://" + authority
 }
 
 func Parse(bs []byte) Request {
-	requestLine := bytes.Split(bs, []byte("\r\n"))[0]
-	method, requestUri, protocolVersion := parseRequestLine(requestLine)
+	head, body := splitHeadAndBody(bs)
+	lines := bytes.Split(head, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+	method, requestUri, protocolVersion := parseRequestLine(lines[0])
 	path, query := parseRequestUri(requestUri)
 
-	headers := parseHeaders(bs)
+	headers := parseHeaders(lines[1:])
+	cookies := takeCookies(headers)
+
+	return Request{Method: method, RequestUri: requestUri, Path: path, Query: query,
+		ProtocolVersion: protocolVersion, Headers: headers, Cookies: cookies, Body: body,
+		headerLines: parseHeaderLines(lines[1:])}
+}
 
+func takeCookies(headers map[string]string) map[string]string {
 	cookies := map[string]string{}
-	if rawCookies, ok := headers["Cookie"]; ok {
-		delete(headers, "Cookie")
-		parseRawCookies(cookies, rawCookies)
+	if key, ok := headerKey(headers, "Cookie"); ok {
+		parseRawCookies(cookies, headers[key])
+		delete(headers, key)
 	}
-
-	body := extractBody(bs)
-	return Request{Method: method, RequestUri: requestUri, Path: path, Query: query,
-		ProtocolVersion: protocolVersion, Headers: headers, Cookies: cookies, Body: body}
+	return cookies
 }
 
 func parseRequestLine(requestLine []byte) (method, requestUri, protocolVersion string) {
-	spaceSplitted := bytes.Split(requestLine, []byte(" "))
+	spaceSplitted := bytes.SplitN(requestLine, []byte(" "), 3)
 	method = string(spaceSplitted[0])
-	requestUri = string(spaceSplitted[1])
-	protocolVersion = string(spaceSplitted[2])
+	if len(spaceSplitted) > 1 {
+		requestUri = string(spaceSplitted[1])
+	}
+	if len(spaceSplitted) > 2 {
+		protocolVersion = string(spaceSplitted[2])
+	}
 	return
 }
 
 func parseRequestUri(requestUri string) (path, query string) {
-	if i := strings.Index(requestUri, "?"); i > 0 {
-		path = requestUri[:i]
-		query = requestUri[i+1:]
+	_, originForm := splitAbsoluteUri(requestUri)
+	if i := strings.Index(originForm, "?"); i >= 0 {
+		path = originForm[:i]
+		query = originForm[i+1:]
 	} else {
-		path = requestUri
+		path = originForm
 	}
 	return
 }
 
-func parseHeaders(rawReq []byte) (headers map[string]string) {
-	headers = make(map[string]string)
-	for _, rawHeader := range bytes.Split(rawReq, []byte("\r\n"))[1:] {
+func splitAbsoluteUri(requestUri string) (schemeAndAuthority, originForm string) {
+	lower := strings.ToLower(requestUri)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return "", requestUri
+	}
+	authorityStart := strings.Index(requestUri, "//") + 2
+	end := strings.IndexAny(requestUri[authorityStart:], "/?")
+	if end == -1 {
+		return requestUri, ""
+	}
+	return requestUri[:authorityStart+end], requestUri[authorityStart+end:]
+}
+
+func parseHeaders(lines [][]byte) (headers map[string]string) {
+	headers = make(map[string]string, len(lines))
+	for _, rawHeader := range lines {
 		if len(rawHeader) == 0 {
 			break
 		}
@@ -88,18 +217,45 @@ func parseHeaders(rawReq []byte) (headers map[string]string) {
 	return
 }
 
+func parseHeaderLines(lines [][]byte) []headerLine {
+	result := []headerLine{}
+	for _, rawHeader := range lines {
+		if len(rawHeader) == 0 {
+			break
+		}
+		name, _ := parseHeader(rawHeader)
+		result = append(result, headerLine{name, string(rawHeader)})
+	}
+	return result
+}
+
 func parseHeader(rawHeader []byte) (name, val string) {
-	colonSplitted := bytes.SplitN(rawHeader, []byte(":"), 2)
-	name = string(colonSplitted[0])
-	val = string(colonSplitted[1])
-	val = strings.TrimSpace(val)
+	rawName, rawVal, found := bytes.Cut(rawHeader, []byte(":"))
+	name = string(rawName)
+	if found {
+		val = string(bytes.TrimSpace(rawVal))
+	}
 	return
 }
 
+// splitHeadAndBody also accepts bare LF line endings, as in files saved by hand,
+// splitting at whichever blank line comes first.
+func splitHeadAndBody(raw []byte) (head, body []byte) {
+	at, size := -1, 0
+	for _, delim := range [][]byte{[]byte("\r\n\r\n"), []byte("\n\n")} {
+		if i := bytes.Index(raw, delim); i != -1 && (at == -1 || i < at) {
+			at, size = i, len(delim)
+		}
+	}
+	if at == -1 {
+		return raw, []byte{}
+	}
+	return raw[:at], raw[at+size:]
+}
+
 func extractBody(raw []byte) []byte {
-	twoRns := []byte("\r\n\r\n")
-	bodyIndex := bytes.Index(raw, twoRns) + len(twoRns)
-	return raw[bodyIndex:]
+	_, body := splitHeadAndBody(raw)
+	return body
 }
 
 func parseRawCookies(cookies map[string]string, raw string) {
@@ -111,7 +267,14 @@ func parseRawCookies(cookies map[string]string, raw string) {
 }
 
 func (r Request) asHttpReq(host string) *http.Request {
-	url := host + r.RequestUri
+	_, isUnix := unixSocket(host)
+	if isUnix {
+		host = "http://localhost"
+	}
+	if target, err := ParseTarget(host); err == nil {
+		host = target.String()
+	}
+	url := host + r.originForm()
 	var body io.Reader
 	if len(r.Body) > 0 {
 		body = bytes.NewBuffer(r.Body)
@@ -127,47 +290,145 @@ func (r Request) asHttpReq(host string) *http.Request {
 	for key, val := range r.Headers {
 		req.Header.Set(key, val)
 	}
+	if h, ok := r.Header("Host"); ok && isUnix {
+		req.Host = h
+	}
 
-	for key, val := range r.Cookies {
-		c := &http.Cookie{Name: key, Value: val}
+	for _, key := range utils.SortedKeys(r.Cookies) {
+		c := &http.Cookie{Name: key, Value: r.Cookies[key]}
 		req.AddCookie(c)
 	}
 	return req
 }
 
+func (r Request) streamsBody() bool {
+	return r.BodyFile != "" && len(r.Body) == 0
+}
+
 func (r Request) Send(host string) (Response, error) {
 	req := r.asHttpReq(host)
+	if r.streamsBody() {
+		f, err := os.Open(r.BodyFile)
+		if err != nil {
+			return Response{}, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return Response{}, err
+		}
+		req.Body = f
+		req.ContentLength = fi.Size()
+	}
 
+	defer globalHostLimiter.acquire(host)()
+	globalThrottle.wait()
+	start := time.Now()
 	client := &http.Client{}
 	res, err := client.Do(req)
 	if err != nil {
 		return Response{}, err
 	}
-	raw, err := httputil.DumpResponse(res, true)
+	if res.StatusCode == http.StatusTooManyRequests {
+		globalThrottle.backOff(retryAfter(res))
+	}
+	response, err := toResponse(res)
+	response.Duration = time.Since(start)
+	return response, err
+}
+
+func toResponse(res *http.Response) (Response, error) {
+	var reader io.Reader = res.Body
+	if MaxBodyBytes > 0 {
+		reader = io.LimitReader(res.Body, MaxBodyBytes+1)
+	}
+	body, err := io.ReadAll(reader)
+	res.Body.Close()
+	if err != nil {
+		return Response{}, err
+	}
+
+	truncated := MaxBodyBytes > 0 && int64(len(body)) > MaxBodyBytes
+	if truncated {
+		body = body[:MaxBodyBytes]
+	}
 
 	contentLen := res.ContentLength
-	if contentLen == -1 {
-		contentLen = int64(len(extractBody(raw)))
+	if contentLen == -1 || truncated {
+		contentLen = int64(len(body))
 	}
 
-	return Response{res.StatusCode, contentLen, raw}, nil
+	// dump the already decoded body, so that Raw holds no chunked framing
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.TransferEncoding = nil
+	raw, _ := httputil.DumpResponse(res, true)
+
+	response := Response{Code: res.StatusCode, Length: contentLen, Raw: raw, Headers: res.Header, Truncated: truncated}
+	return response.Cached(), nil
 }
 
 func (r Request) Raw(host string) []byte {
+	if r.streamsBody() {
+		bs, _ := httputil.DumpRequestOut(r.asHttpReq(host), false)
+		return append(bs, []byte("<body streamed from "+r.BodyFile+">")...)
+	}
 	bs, _ := httputil.DumpRequestOut(r.asHttpReq(host), true)
 	return bs
 }
 
+func (r Request) Url(host string) string {
+	return r.asHttpReq(host).URL.String()
+}
+
+func (r Request) originForm() string {
+	_, originForm := splitAbsoluteUri(r.RequestUri)
+	if originForm == "" || originForm[0] == '?' {
+		return "/" + originForm
+	}
+	return originForm
+}
+
+func (r Request) WithMethod(method string) Request {
+	result := r.Clone()
+	result.Method = method
+	return result
+}
+
 func (r Request) WithPath(path string) Request {
 	result := r.Clone()
-	result.RequestUri = strings.Replace(r.RequestUri, r.Path, path, 1)
+	prefix, originForm := splitAbsoluteUri(r.RequestUri)
+	result.RequestUri = prefix + strings.Replace(originForm, r.Path, path, 1)
 	result.Path = path
 	return result
 }
 
+func (r Request) PathSegments() []string {
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(r.Path, "/"), "/")
+	if trimmed == "" {
+		return []string{}
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func (r Request) WithPathSegment(index int, val string) Request {
+	segments := r.PathSegments()
+	if index < 0 || index >= len(segments) {
+		return r.Clone()
+	}
+	segments[index] = val
+
+	path := "/" + strings.Join(segments, "/")
+	if len(r.Path) > 1 && strings.HasSuffix(r.Path, "/") {
+		path += "/"
+	}
+	return r.WithPath(path)
+}
+
 func (r Request) WithQuery(query string) Request {
 	result := r.Clone()
-	result.RequestUri = strings.Replace(r.RequestUri, r.Query, query, 1)
+	prefix, originForm := splitAbsoluteUri(r.RequestUri)
+	result.RequestUri = prefix + strings.Replace(originForm, r.Query, query, 1)
 	result.Query = query
 	return result
 }
@@ -178,12 +439,76 @@ func (r Request) WithBody(body []byte) Request {
 	return result
 }
 
+func (r Request) WithBodyFile(path string) (Request, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return r, err
+	}
+	result := r.WithBody([]byte{})
+	result.BodyFile = path
+	key, ok := headerKey(result.Headers, "Content-Length")
+	if !ok {
+		key = "Content-Length"
+	}
+	result.Headers[key] = strconv.FormatInt(fi.Size(), 10)
+	return result, nil
+}
+
+// WithContentLength sets the Content-Length, if there is one, to the length of the body
+func (r Request) WithContentLength() Request {
+	return r.withFixedBody(r.Body)
+}
+
+func (r Request) withFixedBody(body []byte) Request {
+	result := r.WithBody(body)
+	if key, ok := headerKey(result.Headers, "Content-Length"); ok {
+		result.Headers[key] = strconv.Itoa(len(body))
+	}
+	return result
+}
+
 func (r Request) WithHeader(key, val string) Request {
 	result := r.Clone()
 	result.Headers[key] = val
 	return result
 }
 
+// withHeaderReplaced overwrites the header under the case the request file gave it, if any
+func (r Request) withHeaderReplaced(name, val string) Request {
+	if key, ok := headerKey(r.Headers, name); ok {
+		name = key
+	}
+	return r.WithHeader(name, val)
+}
+
+func (r Request) WithIdentityEncoding() Request {
+	return r.withHeaderReplaced("Accept-Encoding", "identity")
+}
+
+func (r Request) WithConnectionClose() Request {
+	return r.withHeaderReplaced("Connection", "close")
+}
+
+var hopByHopHeaders = []string{"Connection", "Proxy-Connection", "Keep-Alive", "Transfer-Encoding", "TE", "Trailer", "Upgrade"}
+
+// WithoutHopByHopHeaders removes the headers meant for a single connection, including the ones
+// listed in the Connection header
+func (r Request) WithoutHopByHopHeaders() Request {
+	result := r.Clone()
+	names := append([]string{}, hopByHopHeaders...)
+	if conn, ok := r.Header("Connection"); ok {
+		for _, name := range strings.Split(conn, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	for _, name := range names {
+		if key, ok := headerKey(result.Headers, name); ok {
+			delete(result.Headers, key)
+		}
+	}
+	return result
+}
+
 func (r Request) WithCookie(key, val string) Request {
 	result := r.Clone()
 	result.Cookies[key] = val
@@ -206,7 +531,26 @@ func (r Request) WithHeaderString(header string) Request {
 
 func (r Request) Clone() Request {
 	return Request{Method: r.Method, RequestUri: r.RequestUri, Path: r.Path, Query: r.Query,
-		ProtocolVersion: r.ProtocolVersion, Headers: copyMap(r.Headers), Cookies: copyMap(r.Cookies), Body: r.Body}
+		ProtocolVersion: r.ProtocolVersion, Headers: copyMap(r.Headers), Cookies: copyMap(r.Cookies), Body: r.Body, BodyFile: r.BodyFile, Target: r.Target, Wire: r.Wire, headerLines: r.headerLines}
+}
+
+// Header looks the header up case-insensitively, preferring an exact match.
+func (r Request) Header(name string) (string, bool) {
+	key, ok := headerKey(r.Headers, name)
+	return r.Headers[key], ok
+}
+
+func headerKey(headers map[string]string, name string) (string, bool) {
+	if _, ok := headers[name]; ok {
+		return name, true
+	}
+	canonical := textproto.CanonicalMIMEHeaderKey(name)
+	for _, key := range utils.SortedKeys(headers) {
+		if textproto.CanonicalMIMEHeaderKey(key) == canonical {
+			return key, true
+		}
+	}
+	return "", false
 }
 
 func copyMap(hs map[string]string) map[string]string {
@@ -218,8 +562,8 @@ func copyMap(hs map[string]string) map[string]string {
 }
 
 func (r Request) HasJsonBody() bool {
-	ct, ok := r.Headers["Content-Type"]
-	return ok && ct == "application/json"
+	ct, ok := r.Header("Content-Type")
+	return ok && ct == "application/json" || !ok && r.sniffBody() == sniffedJson
 }
 
 func (r Request) HasJsonCookie(key string) bool {
@@ -231,15 +575,166 @@ func (r Request) HasJsonCookie(key string) bool {
 }
 
 func (r Request) HasFormUrlEncodedBody() bool {
-	ct, ok := r.Headers["Content-Type"]
-	return ok && ct == "application/x-www-form-urlencoded"
+	ct, ok := r.Header("Content-Type")
+	return ok && ct == "application/x-www-form-urlencoded" || !ok && r.sniffBody() == sniffedForm
+}
+
+const (
+	sniffedJson = "json"
+	sniffedXml  = "xml"
+	sniffedForm = "form"
+)
+
+var formBody = regexp.MustCompile(`^[^=&\s]+=[^&\s]*(&[^=&\s]+=[^&\s]*)*$`)
+
+// sniffBody guesses the format of a body sent without a Content-Type, as PUT and PATCH requests
+// sometimes are, so that it is fuzzed like a declared one
+func (r Request) sniffBody() string {
+	body := bytes.TrimSpace(r.Body)
+	switch {
+	case len(body) == 0:
+		return ""
+	case (body[0] == '{' || body[0] == '[') && json.Valid(body):
+		return sniffedJson
+	case body[0] == '<' && isXml(body):
+		return sniffedXml
+	case formBody.Match(body):
+		return sniffedForm
+	}
+	return ""
 }
 
 func (r Request) HasMultipartFormBody() bool {
-	ct, ok := r.Headers["Content-Type"]
+	ct, ok := r.Header("Content-Type")
 	return ok && strings.HasPrefix(ct, "multipart/form-data")
 }
 
+// Class is the first digit of the status code, e.g. 4 for 404
+func (res Response) Class() int {
+	return res.Code / 100
+}
+
+func (res Response) IsInformational() bool {
+	return res.Class() == 1
+}
+
+func (res Response) IsSuccess() bool {
+	return res.Class() == 2
+}
+
+func (res Response) IsRedirect() bool {
+	return res.Class() == 3
+}
+
+func (res Response) IsClientError() bool {
+	return res.Class() == 4
+}
+
+func (res Response) IsServerError() bool {
+	return res.Class() == 5
+}
+
+// Header returns the first value of the header, looked up case-insensitively.
+func (res Response) Header(name string) string {
+	return http.Header(res.Headers).Get(name)
+}
+
+func (res Response) HeaderValues(name string) []string {
+	return http.Header(res.Headers).Values(name)
+}
+
+// Cached returns the response with the head and body split up front, so that the matchers calling
+// Body do not search Raw for the blank line each. The response stays a read-only value, safe to share.
+func (res Response) Cached() Response {
+	head, body := splitHeadAndBody(res.Raw)
+	if len(head) < len(res.Raw) {
+		res.bodyAt = len(res.Raw) - len(body)
+	}
+	return res
+}
+
+func (res Response) Body() []byte {
+	if res.bodyAt > 0 {
+		return res.Raw[res.bodyAt:]
+	}
+	return extractBody(res.Raw)
+}
+
+// TrimmedRaw is Raw with the body cut to max bytes and a note of how much was left out, for
+// saving and printing. 0 keeps the whole body.
+func (res Response) TrimmedRaw(max int) []byte {
+	body := res.Body()
+	if max <= 0 || len(body) <= max {
+		return res.Raw
+	}
+	head := res.Raw[:len(res.Raw)-len(body)]
+	trimmed := append(append([]byte{}, head...), body[:max]...)
+	return append(trimmed, fmt.Sprintf("\r\n[... %v more bytes trimmed, see -trim-body]", len(body)-max)...)
+}
+
+func (res Response) Words() int {
+	words := 0
+	inWord := false
+	for body := res.Body(); len(body) > 0; {
+		r, size := utf8.DecodeRune(body)
+		body = body[size:]
+		if unicode.IsSpace(r) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+	return words
+}
+
+// Lines returns the number of lines in the body. A trailing newline does not start a new line.
+func (res Response) Lines() int {
+	body := res.Body()
+	if len(body) == 0 {
+		return 0
+	}
+	lines := bytes.Count(body, []byte("\n"))
+	if body[len(body)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+func (res Response) Reflects(payload []byte, encoded bool) bool {
+	body := res.Body()
+	if len(payload) == 0 {
+		return false
+	}
+	if bytes.Contains(body, payload) {
+		return true
+	}
+	if !encoded {
+		return false
+	}
+	for _, enc := range EncodedForms(string(payload)) {
+		if bytes.Contains(body, []byte(enc)) {
+			return true
+		}
+	}
+	return false
+}
+
+func EncodedForms(payload string) []string {
+	return []string{html.EscapeString(payload), url.QueryEscape(payload), url.PathEscape(payload)}
+}
+
+const maxBodyPreview = 64
+
+func (r Request) String() string {
+	body := string(r.Body)
+	if len(body) > maxBodyPreview {
+		body = body[:maxBodyPreview] + "..."
+	}
+	return fmt.Sprintf("[Method: %v, Uri: %v, Protocol: %v, Headers: %v, Cookies: %v, Len: %v, Body: %q]",
+		r.Method, r.RequestUri, r.ProtocolVersion, utils.SortedKeys(r.Headers), utils.SortedKeys(r.Cookies), len(r.Body), body)
+}
+
 func (res Response) String() string {
 	return fmt.Sprintf("[Code: %v, Len: %v]", res.Code, res.Length)
 }
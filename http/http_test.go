@@ -1,7 +1,17 @@
 package http
 
 import (
+	"bytes"
+	"compress/gzip"
 	"github.com/kamil-s-solecki/haze/testutils"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -85,6 +95,15 @@ func TestBody(t *testing.T) {
 	testutils.AssertByteEquals(t, got, want)
 }
 
+func TestBodyWithLfHeadKeepsCrlfInBody(t *testing.T) {
+	req := []byte("POST / HTTP/1.1\nContent-Type: text/plain\n\nfoo\r\n\r\nbar")
+
+	rq := Parse(req)
+
+	testutils.AssertByteEquals(t, rq.Body, []byte("foo\r\n\r\nbar"))
+	testutils.AssertEquals(t, rq.Headers["Content-Type"], "text/plain")
+}
+
 func TestPath(t *testing.T) {
 	cases := []struct {
 		req  []byte
@@ -196,3 +215,548 @@ func TestResponseStringer(t *testing.T) {
 		testutils.AssertEquals(t, got, c.str)
 	}
 }
+
+func TestRequestStringer(t *testing.T) {
+	rq := Parse([]byte("POST /foo?a=1 HTTP/1.1\r\nHost: localhost\r\nContent-Type: text/plain\r\nCookie: sid=1; b=2\r\n\r\nhello"))
+
+	testutils.AssertEquals(t, rq.String(),
+		`[Method: POST, Uri: /foo?a=1, Protocol: HTTP/1.1, Headers: [Content-Type Host], Cookies: [b sid], Len: 5, Body: "hello"]`)
+}
+
+func TestRequestStringerTruncatesBody(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\n\r\n" + strings.Repeat("a", 10000)))
+
+	got := rq.String()
+
+	testutils.AssertTrue(t, strings.Contains(got, "Len: 10000, Body: \""+strings.Repeat("a", 64)+"...\"]"))
+	testutils.AssertTrue(t, len(got) < 200)
+}
+
+func TestResponseHeader(t *testing.T) {
+	res := Response{Headers: map[string][]string{
+		"Content-Type": {"application/json"},
+		"Set-Cookie":   {"a=1", "b=2"},
+	}}
+
+	testutils.AssertEquals(t, res.Header("content-type"), "application/json")
+	testutils.AssertEquals(t, strings.Join(res.HeaderValues("Content-Type"), ","), "application/json")
+	testutils.AssertEquals(t, res.Header("Set-Cookie"), "a=1")
+	testutils.AssertEquals(t, strings.Join(res.HeaderValues("set-cookie"), ","), "a=1,b=2")
+	testutils.AssertEquals(t, res.Header("Location"), "")
+	testutils.AssertLen(t, res.HeaderValues("Location"), 0)
+	testutils.AssertEquals(t, Response{}.Header("Location"), "")
+}
+
+func TestResponseBody(t *testing.T) {
+	res := Response{Raw: []byte("HTTP/1.1 200 OK\r\nContent-Length: 3\r\n\r\nfoo")}
+
+	testutils.AssertByteEquals(t, res.Body(), []byte("foo"))
+}
+
+func TestTrimmedRaw(t *testing.T) {
+	res := Response{Raw: []byte("HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\n0123456789")}.Cached()
+
+	testutils.AssertEquals(t, string(res.TrimmedRaw(4)), "HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\n0123\r\n[... 6 more bytes trimmed, see -trim-body]")
+	testutils.AssertByteEquals(t, res.TrimmedRaw(10), res.Raw)
+	testutils.AssertByteEquals(t, res.TrimmedRaw(0), res.Raw)
+	testutils.AssertEquals(t, len(res.Body()), 10)
+}
+
+func TestCachedResponseBody(t *testing.T) {
+	for _, raw := range []string{"HTTP/1.1 200 OK\r\n\r\nfoo\r\n\r\nbar", "HTTP/1.1 200 OK\n\nfoo", "HTTP/1.1 200 OK\r\n\r\n", "HTTP/1.1 200 OK"} {
+		res := Response{Raw: []byte(raw)}
+
+		testutils.AssertByteEquals(t, res.Cached().Body(), res.Body())
+	}
+}
+
+func TestResponseWords(t *testing.T) {
+	cases := []struct {
+		body  string
+		words int
+	}{
+		{"", 0},
+		{" \t\r\n", 0},
+		{"foo", 1},
+		{"foo bar", 2},
+		{" foo\tbar\r\nbaz \n", 3},
+	}
+
+	for _, c := range cases {
+		res := Response{Raw: []byte("HTTP/1.1 200 OK\r\n\r\n" + c.body)}
+
+		testutils.AssertEquals(t, res.Words(), c.words)
+	}
+}
+
+func TestResponseLines(t *testing.T) {
+	cases := []struct {
+		body  string
+		lines int
+	}{
+		{"", 0},
+		{"foo", 1},
+		{"foo\n", 1},
+		{"foo\nbar", 2},
+		{"foo\nbar\n", 2},
+		{"\n", 1},
+		{"\n\n", 2},
+		{"foo\r\nbar\r\n", 2},
+	}
+
+	for _, c := range cases {
+		res := Response{Raw: []byte("HTTP/1.1 200 OK\r\n\r\n" + c.body)}
+
+		testutils.AssertEquals(t, res.Lines(), c.lines)
+	}
+}
+
+func TestRequestLineForms(t *testing.T) {
+	cases := []struct {
+		req                     []byte
+		requestUri, path, query string
+		originForm              string
+	}{
+		{[]byte("GET /foo?x=1 HTTP/1.1\r\nHost: example.com\r\n\r\n"), "/foo?x=1", "/foo", "x=1", "/foo?x=1"},
+		{[]byte("GET /foo HTTP/1.1\r\nHost: example.com\r\n\r\n"), "/foo", "/foo", "", "/foo"},
+		{[]byte("GET http://example.com/foo?x=1 HTTP/1.1\r\nHost: example.com\r\n\r\n"), "http://example.com/foo?x=1", "/foo", "x=1", "/foo?x=1"},
+		{[]byte("GET https://example.com:8443/foo/bar HTTP/1.1\r\nHost: example.com\r\n\r\n"), "https://example.com:8443/foo/bar", "/foo/bar", "", "/foo/bar"},
+		{[]byte("GET http://example.com HTTP/1.1\r\nHost: example.com\r\n\r\n"), "http://example.com", "", "", "/"},
+		{[]byte("GET http://example.com?x=1 HTTP/1.1\r\nHost: example.com\r\n\r\n"), "http://example.com?x=1", "", "x=1", "/?x=1"},
+	}
+
+	for _, c := range cases {
+		rq := Parse(c.req)
+
+		testutils.AssertEquals(t, rq.RequestUri, c.requestUri)
+		testutils.AssertEquals(t, rq.Path, c.path)
+		testutils.AssertEquals(t, rq.Query, c.query)
+		testutils.AssertEquals(t, rq.originForm(), c.originForm)
+	}
+}
+
+func TestMutateAbsoluteFormRequestUri(t *testing.T) {
+	rq := Parse([]byte("GET http://foo/foo?foo=foo HTTP/1.1\r\nHost: foo\r\n\r\n"))
+
+	withPath := rq.WithPath("/bar")
+	withQuery := rq.WithQuery("foo=bar")
+
+	testutils.AssertEquals(t, withPath.RequestUri, "http://foo/bar?foo=foo")
+	testutils.AssertEquals(t, withQuery.RequestUri, "http://foo/foo?foo=bar")
+	testutils.AssertEquals(t, withPath.asHttpReq("http://target").URL.String(), "http://target/bar?foo=foo")
+}
+
+func TestExtractBodyWithoutDelimiter(t *testing.T) {
+	cases := [][]byte{
+		[]byte(""),
+		[]byte("GET"),
+		[]byte("GET /foo HTTP/1.1\r\nHost: example.com\r\n"),
+		[]byte("GET /foo HTTP/1.1\r\nHost: example.com"),
+	}
+
+	for _, c := range cases {
+		testutils.AssertByteEquals(t, extractBody(c), []byte{})
+	}
+}
+
+func TestParseRequestWithoutBlankLine(t *testing.T) {
+	rq := Parse([]byte("GET /foo HTTP/1.1\r\nHost: example.com"))
+
+	testutils.AssertEquals(t, rq.Method, "GET")
+	testutils.AssertEquals(t, rq.Path, "/foo")
+	testutils.AssertMapEquals(t, rq.Headers, map[string]string{"Host": "example.com"})
+	testutils.AssertByteEquals(t, rq.Body, []byte{})
+}
+
+func TestParseEmptyInput(t *testing.T) {
+	rq := Parse([]byte(""))
+
+	testutils.AssertEquals(t, rq.Method, "")
+	testutils.AssertEquals(t, rq.RequestUri, "")
+	testutils.AssertByteEquals(t, rq.Body, []byte{})
+}
+
+func TestEmptyResponseBodyWithoutDelimiter(t *testing.T) {
+	res := Response{Raw: []byte("HTTP/1.1 200 OK\r\nContent-Length: 0")}
+
+	testutils.AssertByteEquals(t, res.Body(), []byte{})
+}
+
+func TestWithPathSegment(t *testing.T) {
+	cases := []struct {
+		req, val  string
+		index     int
+		path, uri string
+	}{
+		{"GET /api/v1/users/123?foo=bar HTTP/1.1\r\n\r\n", "v2", 1, "/api/v2/users/123", "/api/v2/users/123?foo=bar"},
+		{"GET /api/v1/users/123?foo=bar HTTP/1.1\r\n\r\n", "456", 3, "/api/v1/users/456", "/api/v1/users/456?foo=bar"},
+		{"GET /api/v1/users/ HTTP/1.1\r\n\r\n", "admins", 2, "/api/v1/admins/", "/api/v1/admins/"},
+		{"GET /api/v1/users/ HTTP/1.1\r\n\r\n", "foo", 3, "/api/v1/users/", "/api/v1/users/"},
+		{"GET /api HTTP/1.1\r\n\r\n", "foo", -1, "/api", "/api"},
+		{"GET / HTTP/1.1\r\n\r\n", "foo", 0, "/", "/"},
+	}
+
+	for _, c := range cases {
+		got := Parse([]byte(c.req)).WithPathSegment(c.index, c.val)
+
+		testutils.AssertEquals(t, got.Path, c.path)
+		testutils.AssertEquals(t, got.RequestUri, c.uri)
+	}
+}
+
+func TestReflects(t *testing.T) {
+	payload := []byte(`"><script>alert(1)</script>`)
+	cases := []struct {
+		body             string
+		raw, withEncoded bool
+	}{
+		{`<div>"><script>alert(1)</script></div>`, true, true},
+		{`<div>&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;</div>`, false, true},
+		{`<a href="/?q=%22%3E%3Cscript%3Ealert%281%29%3C%2Fscript%3E">`, false, true},
+		{`<div>nothing to see</div>`, false, false},
+	}
+
+	for _, c := range cases {
+		res := Response{Raw: []byte("HTTP/1.1 200 OK\r\n\r\n" + c.body)}
+
+		testutils.AssertEquals(t, res.Reflects(payload, false), c.raw)
+		testutils.AssertEquals(t, res.Reflects(payload, true), c.withEncoded)
+	}
+}
+
+func TestReflectsOnlyInBody(t *testing.T) {
+	res := Response{Raw: []byte("HTTP/1.1 200 OK\r\nX-Echo: foo'\r\n\r\nbar")}
+
+	testutils.AssertFalse(t, res.Reflects([]byte("foo'"), true))
+}
+
+func TestShouldSendToUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "haze.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skip("unix sockets not supported: ", err)
+	}
+	gotHost := ""
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		w.Write([]byte("via " + r.URL.Path))
+	}))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+
+	SetupTransport(TransportOptions{Host: "unix:" + socket})
+	rq := Parse([]byte("GET /sock HTTP/1.1\r\nHost: api.internal\r\n\r\n"))
+	res, err := rq.Send("unix:" + socket)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, res.Code, 200)
+	testutils.AssertByteEquals(t, res.Body(), []byte("via /sock"))
+	testutils.AssertEquals(t, gotHost, "api.internal")
+}
+
+func TestShouldRequestUncompressedResponse(t *testing.T) {
+	body := "hello hello hello hello"
+	gotEncoding := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Accept-Encoding")
+		if strings.Contains(gotEncoding, "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(body))
+			gz.Close()
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nAccept-Encoding: gzip, deflate\r\n\r\n"))
+
+	res, err := rq.WithIdentityEncoding().Send(srv.URL)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, gotEncoding, "identity")
+	testutils.AssertByteEquals(t, res.Body(), []byte(body))
+	testutils.AssertEquals(t, res.Length, int64(len(body)))
+}
+
+func TestIdentityEncodingReplacesLowercaseHeader(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\naccept-encoding: gzip\r\n\r\n"))
+
+	got := rq.WithIdentityEncoding()
+
+	testutils.AssertMapEquals(t, got.Headers, map[string]string{"Host": "localhost", "accept-encoding": "identity"})
+	testutils.AssertByteEquals(t, got.WireBytes(), []byte("GET / HTTP/1.1\r\nHost: localhost\r\naccept-encoding: identity\r\n\r\n"))
+}
+
+func TestShouldOpenFreshConnectionsWithoutKeepAlive(t *testing.T) {
+	conns := map[string]bool{}
+	gotConnection := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conns[r.RemoteAddr] = true
+		gotConnection = append(gotConnection, r.Header.Get("Connection"))
+	}))
+	defer srv.Close()
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n")).WithConnectionClose()
+
+	SetupTransport(TransportOptions{Host: srv.URL, NoKeepAlive: true})
+	for i := 0; i < 3; i++ {
+		_, err := rq.Send(srv.URL)
+		testutils.AssertTrue(t, err == nil)
+	}
+
+	testutils.AssertEquals(t, len(conns), 3)
+	testutils.AssertEquals(t, strings.Join(gotConnection, ","), "close,close,close")
+}
+
+func TestConnectionCloseReplacesLowercaseHeader(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nconnection: keep-alive\r\n\r\n"))
+
+	got := rq.WithConnectionClose()
+
+	testutils.AssertMapEquals(t, got.Headers, map[string]string{"Host": "localhost", "connection": "close"})
+	testutils.AssertByteEquals(t, got.WireBytes(), []byte("GET / HTTP/1.1\r\nHost: localhost\r\nconnection: close\r\n\r\n"))
+}
+
+func TestWithoutHopByHopHeaders(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive, X-Hop\r\nProxy-Connection: keep-alive\r\n" +
+		"keep-alive: timeout=5\r\nTransfer-Encoding: chunked\r\nUpgrade: h2c\r\nX-Hop: 1\r\nX-Foo: foo\r\n\r\n"))
+
+	got := rq.WithoutHopByHopHeaders()
+
+	for _, name := range []string{"Connection", "Proxy-Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade", "X-Hop"} {
+		_, ok := got.Header(name)
+		testutils.AssertFalse(t, ok)
+	}
+	testutils.AssertEquals(t, got.Headers["Host"], "localhost")
+	testutils.AssertEquals(t, got.Headers["X-Foo"], "foo")
+	testutils.AssertEquals(t, len(rq.Headers), 8)
+}
+
+func TestShouldTruncateOversizedBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer srv.Close()
+	defer func() { MaxBodyBytes = 0 }()
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	full, _ := rq.Send(srv.URL)
+	MaxBodyBytes = 100
+	truncated, err := rq.Send(srv.URL)
+
+	testutils.AssertFalse(t, full.Truncated)
+	testutils.AssertEquals(t, full.Length, int64(1000))
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertTrue(t, truncated.Truncated)
+	testutils.AssertEquals(t, truncated.Length, int64(100))
+	testutils.AssertByteEquals(t, truncated.Body(), []byte(strings.Repeat("a", 100)))
+}
+
+func BenchmarkParse(b *testing.B) {
+	raw := []byte("POST /api/users?id=1&sort=asc HTTP/1.1\r\nHost: www.example.com\r\nUser-Agent: haze\r\n" +
+		"Accept: */*\r\nContent-Type: application/json\r\nCookie: session=abc; theme=dark\r\n" +
+		"Content-Length: 27\r\n\r\n{\"name\":\"bob\",\"admin\":false}")
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		Parse(raw)
+	}
+}
+
+func TestShouldStreamBodyFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	content := bytes.Repeat([]byte("0123456789abcdef"), 3*1024*1024/16)
+	os.WriteFile(path, content, 0644)
+	var gotLen int64
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLen = r.ContentLength
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+	rq := Parse([]byte("POST /upload?id=1 HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\n\r\nhello"))
+
+	rq, err := rq.WithBodyFile(path)
+	testutils.AssertTrue(t, err == nil)
+	for _, send := range []func(string) (Response, error){rq.Send, rq.SendRaw} {
+		gotLen, gotBody = 0, nil
+		res, err := send(srv.URL)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, 200)
+		testutils.AssertEquals(t, gotLen, int64(len(content)))
+		testutils.AssertTrue(t, bytes.Equal(gotBody, content))
+	}
+	testutils.AssertLen(t, rq.Body, 0)
+	testutils.AssertEquals(t, rq.Headers["Content-Length"], strconv.Itoa(len(content)))
+	testutils.AssertTrue(t, strings.HasSuffix(string(rq.Raw(srv.URL)), "<body streamed from "+path+">"))
+	testutils.AssertEquals(t, rq.WithQuery("id=2").BodyFile, path)
+}
+
+func TestShouldForceProtocol(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	cases := []struct {
+		protocol Protocol
+		proto    string
+	}{
+		{Http2, "HTTP/2.0"},
+		{Http1, "HTTP/1.1"},
+		{Auto, "HTTP/1.1"},
+	}
+
+	for _, c := range cases {
+		SetupTransport(TransportOptions{Host: srv.URL, Protocol: c.protocol})
+
+		res, err := rq.Send(srv.URL)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertByteEquals(t, res.Body(), []byte(c.proto))
+	}
+}
+
+func TestHeaderLookupIsCaseInsensitive(t *testing.T) {
+	cases := []struct {
+		name                  string
+		json, form, multipart bool
+	}{
+		{"content-type: application/json", true, false, false},
+		{"CONTENT-TYPE: application/x-www-form-urlencoded", false, true, false},
+		{"Content-type: multipart/form-data; boundary=abc", false, false, true},
+	}
+
+	for _, c := range cases {
+		rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\n" + c.name + "\r\n\r\n{}"))
+
+		testutils.AssertEquals(t, rq.HasJsonBody(), c.json)
+		testutils.AssertEquals(t, rq.HasFormUrlEncodedBody(), c.form)
+		testutils.AssertEquals(t, rq.HasMultipartFormBody(), c.multipart)
+	}
+}
+
+func TestHeaderPrefersExactMatch(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nx-foo: lower\r\nX-Foo: exact\r\n\r\n"))
+
+	got, ok := rq.Header("X-Foo")
+	testutils.AssertTrue(t, ok)
+	testutils.AssertEquals(t, got, "exact")
+	_, ok = rq.Header("X-Bar")
+	testutils.AssertFalse(t, ok)
+}
+
+func TestCookieHeaderIsCaseInsensitive(t *testing.T) {
+	for _, name := range []string{"cookie", "COOKIE", "CooKie"} {
+		rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n" + name + ": a=1; b=2\r\n\r\n"))
+
+		testutils.AssertEquals(t, rq.Cookies["a"], "1")
+		testutils.AssertEquals(t, rq.Cookies["b"], "2")
+		_, ok := rq.Header("Cookie")
+		testutils.AssertFalse(t, ok)
+	}
+}
+
+func TestContentLengthFixupIsCaseInsensitive(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\ncontent-type: application/x-www-form-urlencoded\r\ncontent-length: 3\r\n\r\na=12"))
+
+	got := rq.WithContentLength()
+
+	testutils.AssertEquals(t, got.Headers["content-length"], "4")
+	_, exact := got.Headers["Content-Length"]
+	testutils.AssertFalse(t, exact)
+}
+
+func TestNormalizeHost(t *testing.T) {
+	cases := []struct {
+		host, want string
+	}{
+		{"http://127.0.0.1:8080", "http://127.0.0.1:8080"},
+		{"http://example.com/", "http://example.com"},
+		{"http://::1", "http://[::1]"},
+		{"https://2001:db8::1", "https://[2001:db8::1]"},
+		{"https://[2001:db8::1]:8443", "https://[2001:db8::1]:8443"},
+		{"unix:/tmp/app.sock", "unix:/tmp/app.sock"},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, normalizeHost(c.host), c.want)
+	}
+}
+
+func TestShouldBracketIpv6Targets(t *testing.T) {
+	rq := Parse([]byte("GET /foo HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+	cases := []struct {
+		host, url, authority string
+	}{
+		{"http://127.0.0.1:8080", "http://127.0.0.1:8080/foo", "127.0.0.1:8080"},
+		{"http://2001:db8::1", "http://[2001:db8::1]/foo", "[2001:db8::1]"},
+		{"https://[2001:db8::1]:8443", "https://[2001:db8::1]:8443/foo", "[2001:db8::1]:8443"},
+	}
+
+	for _, c := range cases {
+		req := rq.asHttpReq(c.host)
+
+		testutils.AssertEquals(t, req.URL.String(), c.url)
+		testutils.AssertEquals(t, req.Host, c.authority)
+	}
+}
+
+func TestShouldSendToIpv6Target(t *testing.T) {
+	l, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback is not available")
+	}
+	gotHost := make(chan string, 2)
+	srv := &httptest.Server{Listener: l, Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost <- r.Host
+	})}}
+	srv.Start()
+	defer srv.Close()
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+	for _, send := range []func(string) (Response, error){rq.Send, rq.SendRaw} {
+		res, err := send(srv.URL)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, 200)
+	}
+	testutils.AssertEquals(t, <-gotHost, l.Addr().String())
+	testutils.AssertEquals(t, <-gotHost, "example.com")
+}
+
+func TestResponseClasses(t *testing.T) {
+	cases := []struct {
+		code, class                                      int
+		informational, success, redirect, client, server bool
+	}{
+		{199, 1, true, false, false, false, false},
+		{200, 2, false, true, false, false, false},
+		{299, 2, false, true, false, false, false},
+		{300, 3, false, false, true, false, false},
+		{399, 3, false, false, true, false, false},
+		{400, 4, false, false, false, true, false},
+		{499, 4, false, false, false, true, false},
+		{500, 5, false, false, false, false, true},
+		{599, 5, false, false, false, false, true},
+	}
+
+	for _, c := range cases {
+		res := Response{Code: c.code}
+
+		testutils.AssertEquals(t, res.Class(), c.class)
+		testutils.AssertEquals(t, res.IsInformational(), c.informational)
+		testutils.AssertEquals(t, res.IsSuccess(), c.success)
+		testutils.AssertEquals(t, res.IsRedirect(), c.redirect)
+		testutils.AssertEquals(t, res.IsClientError(), c.client)
+		testutils.AssertEquals(t, res.IsServerError(), c.server)
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"bytes"
+)
+
+const httpFileSeparator = "###"
+
+// ToHttpFile renders the request as an entry of a .http file (REST Client, IntelliJ HTTP Client):
+// the serialized request with the target in the request line, under a ### line with the name.
+func (r Request) ToHttpFile(host string, name string) []byte {
+	if target, err := ParseTarget(host); err == nil {
+		host = target.String()
+	}
+	result := r.Clone()
+	result.RequestUri = host + r.originForm()
+
+	entry := []byte(httpFileSeparator + " " + name + "\r\n")
+	entry = append(entry, result.Serialize()...)
+	return append(entry, "\r\n"...)
+}
+
+// ParseHttpFile reads back the requests of a .http file. Lines before the request line
+// which start with # or // are comments.
+func ParseHttpFile(bs []byte) []Request {
+	result := []Request{}
+	for _, entry := range splitHttpFile(bs) {
+		lines := bytes.SplitAfter(entry, []byte("\n"))
+		for len(lines) > 0 && isHttpFileComment(lines[0]) {
+			lines = lines[1:]
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		// the line break before the next ### belongs to the file, not to the body
+		raw := bytes.Join(lines, nil)
+		raw = bytes.TrimSuffix(bytes.TrimSuffix(raw, []byte("\n")), []byte("\r"))
+		result = append(result, Parse(raw))
+	}
+	return result
+}
+
+func splitHttpFile(bs []byte) [][]byte {
+	entries := [][]byte{}
+	start := 0
+	for _, line := range bytes.SplitAfter(bs, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte(httpFileSeparator)) {
+			entries = append(entries, bs[:start])
+			bs = bs[start+len(line):]
+			start = 0
+			continue
+		}
+		start += len(line)
+	}
+	return append(entries, bs)
+}
+
+func isHttpFileComment(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	return len(line) == 0 || bytes.HasPrefix(line, []byte("#")) || bytes.HasPrefix(line, []byte("//"))
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func TestHttpFileRoundTrip(t *testing.T) {
+	rqs := []Request{
+		Parse([]byte("POST /api/users?id=1' HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nCookie: sid=1; lang=en\r\n\r\n{\"a\": \"b\"}\n")),
+		Parse([]byte("GET /search?q=%00 HTTP/1.1\r\nHost: example.com\r\nX-Foo: foo\r\n\r\n")),
+		Parse([]byte("PUT / HTTP/1.1\r\nHost: example.com\r\n\r\nline 1\r\n\r\nline 2\r\n")),
+	}
+	file := []byte("# saved by haze\r\n\r\n")
+	for _, rq := range rqs {
+		file = append(file, rq.ToHttpFile("https://example.com:8443", "SingleQuotes Parameter id")...)
+	}
+
+	got := ParseHttpFile(file)
+
+	testutils.AssertLen(t, got, 3)
+	for i, rq := range rqs {
+		want := rq.Clone()
+		want.RequestUri = "https://example.com:8443" + rq.RequestUri
+
+		testutils.AssertEquals(t, got[i].Path, rq.Path)
+		testutils.AssertEquals(t, got[i].Query, rq.Query)
+		testutils.AssertMapEquals(t, got[i].Cookies, rq.Cookies)
+		testutils.AssertByteEquals(t, got[i].Serialize(), want.Serialize())
+	}
+}
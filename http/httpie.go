@@ -0,0 +1,114 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kamil-s-solecki/haze/utils"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ToHTTPie renders the request as an HTTPie command. JSON objects and url-encoded forms become
+// key=value items, any other body is passed with --raw.
+func (r Request) ToHTTPie(host string) string {
+	cmd := "http"
+	if target, err := ParseTarget(host); err == nil {
+		cmd = target.Scheme
+	}
+	args := []string{cmd}
+
+	items, isForm, ok := r.httpieBodyItems()
+	if isForm {
+		args = append(args, "--form")
+	} else if !ok && len(r.Body) > 0 {
+		args = append(args, "--raw", shellQuote(string(r.Body)))
+	}
+	args = append(args, r.Method, shellQuote(r.Url(host)))
+
+	for _, key := range utils.SortedKeys(r.Headers) {
+		canonical := textproto.CanonicalMIMEHeaderKey(key)
+		if canonical == "Host" || canonical == "Content-Length" {
+			continue
+		}
+		args = append(args, shellQuote(httpieHeader(key, r.Headers[key])))
+	}
+	if len(r.Cookies) > 0 {
+		cookies := []string{}
+		for _, key := range utils.SortedKeys(r.Cookies) {
+			cookies = append(cookies, key+"="+r.Cookies[key])
+		}
+		args = append(args, shellQuote(httpieHeader("Cookie", strings.Join(cookies, "; "))))
+	}
+
+	for _, item := range items {
+		args = append(args, shellQuote(item))
+	}
+	if r.streamsBody() {
+		args = append(args, "<", shellQuote(r.BodyFile))
+	}
+	return strings.Join(args, " ")
+}
+
+func httpieHeader(name, val string) string {
+	// HTTPie drops headers given as "Name:", an empty value is written as "Name;"
+	if val == "" {
+		return name + ";"
+	}
+	return name + ":" + val
+}
+
+// httpieBodyItems returns the body as request items, or ok=false when it has to be sent with --raw
+func (r Request) httpieBodyItems() (items []string, isForm, ok bool) {
+	if len(r.Body) == 0 || r.streamsBody() {
+		return nil, false, false
+	}
+	if r.HasFormUrlEncodedBody() {
+		for _, p := range ParseParams(string(r.Body)) {
+			key, keyErr := url.QueryUnescape(p.Key)
+			val, valErr := url.QueryUnescape(p.Value)
+			if keyErr != nil || valErr != nil || !p.HasValue || !isHttpieKey(key) || strings.HasPrefix(val, "@") {
+				return nil, false, false
+			}
+			items = append(items, key+"="+val)
+		}
+		return items, true, true
+	}
+	if r.HasJsonBody() {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(r.Body, &fields); err != nil || len(fields) == 0 {
+			return nil, false, false
+		}
+		for _, key := range utils.SortedKeys(fields) {
+			if !isHttpieKey(key) {
+				return nil, false, false
+			}
+			var str string
+			isString := bytes.HasPrefix(bytes.TrimSpace(fields[key]), []byte(`"`))
+			if isString && json.Unmarshal(fields[key], &str) == nil && !strings.HasPrefix(str, "@") {
+				items = append(items, key+"="+str)
+				continue
+			}
+			compact := &bytes.Buffer{}
+			json.Compact(compact, fields[key])
+			items = append(items, key+":="+compact.String())
+		}
+		return items, false, true
+	}
+	return nil, false, false
+}
+
+// isHttpieKey tells whether the key can be written as an item without being mistaken for a separator
+func isHttpieKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, ":=@\\[]")
+}
+
+var shellSafe = regexp.MustCompile(`^[-A-Za-z0-9_./:=@%+,]+$`)
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
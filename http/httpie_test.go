@@ -0,0 +1,38 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func TestToHTTPieJsonPost(t *testing.T) {
+	rq := Parse([]byte("POST /api/users?id=1 HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 56\r\n\r\n" +
+		`{"name": "O'Brien", "age": 42, "tags": ["a", "b"], "x": null}`))
+
+	got := rq.ToHTTPie("https://example.com")
+
+	testutils.AssertEquals(t, got, `https POST 'https://example.com/api/users?id=1' Content-Type:application/json `+
+		`age:=42 'name=O'\''Brien' 'tags:=["a","b"]' x:=null`)
+}
+
+func TestToHTTPieGetWithHeaders(t *testing.T) {
+	rq := Parse([]byte("GET /search?q=a+b HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer abc\r\nX-Empty: \r\n" +
+		"Cookie: sid=1; lang=en\r\n\r\n"))
+
+	got := rq.ToHTTPie("http://example.com:8080")
+
+	testutils.AssertEquals(t, got, `http GET 'http://example.com:8080/search?q=a+b' 'Authorization:Bearer abc' 'X-Empty;' 'Cookie:lang=en; sid=1'`)
+}
+
+func TestToHTTPieFormAndRawBodies(t *testing.T) {
+	form := Parse([]byte("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\na=1%27&b=x+y"))
+	raw := Parse([]byte("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: text/plain\r\n\r\nhello 'world'"))
+	brokenJson := Parse([]byte("POST / HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\n\r\n{\"a\": 1'}"))
+
+	testutils.AssertEquals(t, form.ToHTTPie("http://example.com"),
+		`http --form POST http://example.com/ Content-Type:application/x-www-form-urlencoded 'a=1'\''' 'b=x y'`)
+	testutils.AssertEquals(t, raw.ToHTTPie("http://example.com"),
+		`http --raw 'hello '\''world'\''' POST http://example.com/ Content-Type:text/plain`)
+	testutils.AssertEquals(t, brokenJson.ToHTTPie("http://example.com"),
+		`http --raw '{"a": 1'\''}' POST http://example.com/ Content-Type:application/json`)
+}
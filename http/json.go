@@ -0,0 +1,157 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kamil-s-solecki/haze/utils"
+	"strconv"
+	"strings"
+)
+
+type JsonField struct {
+	Path  string
+	Value interface{}
+}
+
+func parseJsonPath(path string) ([]interface{}, error) {
+	steps := []interface{}{}
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+		}
+		if key != "" {
+			steps = append(steps, key)
+		}
+		for rest := part[len(key):]; rest != ""; {
+			end := strings.Index(rest, "]")
+			if rest[0] != '[' || end == -1 {
+				return nil, fmt.Errorf("invalid json path: %v", path)
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid json path: %v", path)
+			}
+			steps = append(steps, idx)
+			rest = rest[end+1:]
+		}
+	}
+	return steps, nil
+}
+
+func setJsonPath(data interface{}, steps []interface{}, val interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return val, nil
+	}
+	switch step := steps[0].(type) {
+	case string:
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			if data != nil {
+				return nil, fmt.Errorf("not an object at %v", step)
+			}
+			obj = map[string]interface{}{}
+		}
+		child, err := setJsonPath(obj[step], steps[1:], val)
+		if err != nil {
+			return nil, err
+		}
+		obj[step] = child
+		return obj, nil
+	default:
+		idx := step.(int)
+		arr, ok := data.([]interface{})
+		if !ok || idx >= len(arr) {
+			return nil, fmt.Errorf("no array element at [%v]", idx)
+		}
+		child, err := setJsonPath(arr[idx], steps[1:], val)
+		if err != nil {
+			return nil, err
+		}
+		arr[idx] = child
+		return arr, nil
+	}
+}
+
+func withJsonPath(raw []byte, path string, val interface{}) ([]byte, error) {
+	steps, err := parseJsonPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	if data, err = setJsonPath(data, steps, val); err != nil {
+		return nil, err
+	}
+	return encodeJson(data)
+}
+
+func encodeJson(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (r Request) WithJsonCookieField(cookieKey, path string, val interface{}) Request {
+	if !r.HasJsonCookie(cookieKey) {
+		return r.Clone()
+	}
+	cookie := strings.Replace(r.Cookies[cookieKey], "%22", "\"", -1)
+	js, err := withJsonPath([]byte(cookie), path, val)
+	if err != nil {
+		return r.Clone()
+	}
+	return r.WithCookie(cookieKey, strings.Replace(string(js), "\"", "%22", -1))
+}
+
+func (r Request) JsonFields() []JsonField {
+	var data interface{}
+	if err := json.Unmarshal(r.Body, &data); err != nil {
+		return []JsonField{}
+	}
+	return jsonLeaves("", data)
+}
+
+func jsonLeaves(path string, data interface{}) []JsonField {
+	fields := []JsonField{}
+	switch d := data.(type) {
+	case map[string]interface{}:
+		for _, k := range utils.SortedKeys(d) {
+			fields = append(fields, jsonLeaves(strings.TrimPrefix(path+"."+k, "."), d[k])...)
+		}
+	case []interface{}:
+		for i, v := range d {
+			fields = append(fields, jsonLeaves(fmt.Sprintf("%v[%v]", path, i), v)...)
+		}
+	default:
+		fields = append(fields, JsonField{path, data})
+	}
+	return fields
+}
+
+func (r Request) WithJsonField(path string, val interface{}) Request {
+	js, err := withJsonPath(r.Body, path, val)
+	if err != nil {
+		return r.Clone()
+	}
+	return r.withFixedBody(js)
+}
+
+func (r Request) HasGraphqlBody() bool {
+	if !r.HasJsonBody() {
+		return false
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(r.Body, &data); err != nil {
+		return false
+	}
+	_, ok := data["query"].(string)
+	return ok
+}
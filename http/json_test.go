@@ -0,0 +1,146 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJsonPath(t *testing.T) {
+	cases := []struct {
+		path  string
+		steps []interface{}
+	}{
+		{"a", []interface{}{"a"}},
+		{"a.b[0].c", []interface{}{"a", "b", 0, "c"}},
+		{"[1][2]", []interface{}{1, 2}},
+	}
+
+	for _, c := range cases {
+		got, err := parseJsonPath(c.path)
+
+		testutils.AssertTrue(t, err == nil)
+		if !reflect.DeepEqual(got, c.steps) {
+			t.Errorf("got %v, wanted %v", got, c.steps)
+		}
+	}
+}
+
+func TestParseInvalidJsonPath(t *testing.T) {
+	for _, path := range []string{"a[x]", "a[1", "a]1["} {
+		_, err := parseJsonPath(path)
+
+		testutils.AssertTrue(t, err != nil)
+	}
+}
+
+func TestWithJsonCookieFieldNested(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nCookie: session={\"user\":{\"roles\":[\"guest\",\"user\"]}}; other=1\r\n\r\n"))
+
+	got := rq.WithJsonCookieField("session", "user.roles[0]", "admin")
+
+	testutils.AssertEquals(t, got.Cookies["session"], "{%22user%22:{%22roles%22:[%22admin%22,%22user%22]}}")
+	testutils.AssertEquals(t, got.Cookies["other"], "1")
+	testutils.AssertEquals(t, rq.Cookies["session"], "{%22user%22:{%22roles%22:[%22guest%22,%22user%22]}}")
+}
+
+func TestWithJsonCookieFieldQuoteRoundTrip(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nCookie: prefs={\"theme\":\"dark\"}\r\n\r\n"))
+
+	got := rq.WithJsonCookieField("prefs", "theme", `x"<y>`)
+
+	testutils.AssertEquals(t, got.Cookies["prefs"], `{%22theme%22:%22x\%22<y>%22}`)
+	testutils.AssertTrue(t, got.HasJsonCookie("prefs"))
+}
+
+func TestWithJsonCookieFieldLeavesNonJsonCookie(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nCookie: id=abc\r\n\r\n"))
+
+	got := rq.WithJsonCookieField("id", "a", 1)
+
+	testutils.AssertEquals(t, got.Cookies["id"], "abc")
+}
+
+func TestJsonFields(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nContent-Type: application/json\r\n\r\n{\"b\":{\"c\":[1,\"x\"]},\"a\":true}"))
+
+	got := rq.JsonFields()
+
+	testutils.AssertLen(t, got, 3)
+	testutils.AssertEquals(t, got[0].Path, "a")
+	testutils.AssertEquals(t, got[1].Path, "b.c[0]")
+	testutils.AssertEquals(t, got[2].Path, "b.c[1]")
+	testutils.AssertEquals(t, got[2].Value.(string), "x")
+}
+
+func TestWithJsonField(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: 15\r\n\r\n{\"a\":{\"b\":\"c\"}}"))
+
+	got := rq.WithJsonField("a.b", "<x>")
+
+	testutils.AssertEquals(t, string(got.Body), "{\"a\":{\"b\":\"<x>\"}}")
+	testutils.AssertEquals(t, got.Headers["Content-Length"], "17")
+}
+
+func TestJsonFieldsOfTopLevelArray(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nContent-Type: application/json\r\n\r\n[1,{\"name\":\"bob\",\"tags\":[[\"a\",\"b\"]]}]"))
+
+	fields := rq.JsonFields()
+
+	paths := []string{}
+	for _, f := range fields {
+		paths = append(paths, f.Path)
+	}
+	testutils.AssertEquals(t, strings.Join(paths, ","), "[0],[1].name,[1].tags[0][0],[1].tags[0][1]")
+	testutils.AssertEquals(t, string(rq.WithJsonField(fields[1].Path, "<x>").Body), `[1,{"name":"<x>","tags":[["a","b"]]}]`)
+	testutils.AssertEquals(t, string(rq.WithJsonField(fields[3].Path, "c").Body), `[1,{"name":"bob","tags":[["a","c"]]}]`)
+}
+
+func TestWithJsonFieldIntoNestedArrays(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nContent-Type: application/json\r\n\r\n[[1,2],[3,[4]]]"))
+
+	testutils.AssertEquals(t, string(rq.WithJsonField("[1][1][0]", "x").Body), `[[1,2],[3,["x"]]]`)
+	testutils.AssertEquals(t, string(rq.WithJsonField("[0][1]", "y").Body), `[[1,"y"],[3,[4]]]`)
+	testutils.AssertEquals(t, string(rq.WithJsonField("[2]", "z").Body), string(rq.Body))
+}
+
+func TestSniffBodyWithoutContentType(t *testing.T) {
+	cases := []struct {
+		method, headers, body string
+		json, xml, form       bool
+	}{
+		{"PATCH", "", `{"name":"bob"}`, true, false, false},
+		{"PUT", "", " [1, 2]\n", true, false, false},
+		{"DELETE", "", "<user><id>1</id></user>", false, true, false},
+		{"PUT", "", "id=1&name=bob", false, false, true},
+		{"PATCH", "", "{broken", false, false, false},
+		{"PUT", "", "plain text", false, false, false},
+		{"PATCH", "Content-Type: text/plain\r\n", `{"name":"bob"}`, false, false, false},
+	}
+
+	for _, c := range cases {
+		rq := Parse([]byte(c.method + " / HTTP/1.1\r\nHost: localhost\r\n" + c.headers + "\r\n" + c.body))
+
+		testutils.AssertEquals(t, rq.HasJsonBody(), c.json)
+		testutils.AssertEquals(t, rq.HasXmlBody(), c.xml)
+		testutils.AssertEquals(t, rq.HasFormUrlEncodedBody(), c.form)
+	}
+}
+
+func TestHasGraphqlBody(t *testing.T) {
+	cases := []struct {
+		body string
+		want bool
+	}{
+		{`{"query":"query { me { id } }","variables":{}}`, true},
+		{`{"query":{"id":1}}`, false},
+		{`{"name":"bob"}`, false},
+	}
+
+	for _, c := range cases {
+		rq := Parse([]byte("POST /graphql HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" + c.body))
+
+		testutils.AssertEquals(t, rq.HasGraphqlBody(), c.want)
+	}
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kamil-s-solecki/haze/utils"
+	"net/url"
+	"strings"
+)
+
+type openApi struct {
+	doc map[string]interface{}
+}
+
+var openApiMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// maxSchemaDepth stops example generation for deeply nested or recursive schemas
+const maxSchemaDepth = 8
+
+// ParseOpenApi synthesizes a request for each operation of an OpenAPI 3 document in JSON.
+// Parameters and bodies get their examples, or placeholder values of the right type.
+func ParseOpenApi(data []byte) ([]Request, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Invalid OpenAPI document: %v", err)
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("Invalid OpenAPI document: only OpenAPI 3 is supported")
+	}
+	spec := openApi{doc}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	result := []Request{}
+	for _, path := range utils.SortedKeys(paths) {
+		item := spec.resolve(paths[path])
+		for _, method := range openApiMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			params := spec.parameters(item["parameters"], op["parameters"])
+			result = append(result, spec.request(strings.ToUpper(method), spec.basePath()+path, params, op))
+		}
+	}
+	return result, nil
+}
+
+func (spec openApi) basePath() string {
+	servers, _ := spec.doc["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	raw, _ := server["url"].(string)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// parameters merges the path item parameters with the operation ones, which take precedence
+func (spec openApi) parameters(lists ...interface{}) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	index := map[string]int{}
+	for _, list := range lists {
+		params, _ := list.([]interface{})
+		for _, p := range params {
+			param := spec.resolve(p)
+			key := fmt.Sprint(param["in"], ":", param["name"])
+			if i, ok := index[key]; ok {
+				result[i] = param
+				continue
+			}
+			index[key] = len(result)
+			result = append(result, param)
+		}
+	}
+	return result
+}
+
+func (spec openApi) request(method, path string, params []map[string]interface{}, op map[string]interface{}) Request {
+	query := []Param{}
+	headers := map[string]string{}
+	cookies := map[string]string{}
+	for _, param := range params {
+		name, _ := param["name"].(string)
+		value := fmt.Sprint(spec.paramExample(param))
+		switch param["in"] {
+		case "path":
+			path = strings.Replace(path, "{"+name+"}", url.PathEscape(value), -1)
+		case "query":
+			query = append(query, Param{url.QueryEscape(name), url.QueryEscape(value), true})
+		case "header":
+			if required, _ := param["required"].(bool); required {
+				headers[name] = value
+			}
+		case "cookie":
+			cookies[name] = url.QueryEscape(value)
+		}
+	}
+
+	body := []byte{}
+	if content, ok := spec.resolve(op["requestBody"])["content"].(map[string]interface{}); ok {
+		if media, ok := content["application/json"].(map[string]interface{}); ok {
+			body, _ = json.Marshal(spec.mediaExample(media))
+			headers["Content-Type"] = "application/json"
+		} else if media, ok := content["application/x-www-form-urlencoded"].(map[string]interface{}); ok {
+			fields, _ := spec.mediaExample(media).(map[string]interface{})
+			form := []Param{}
+			for _, key := range utils.SortedKeys(fields) {
+				form = append(form, Param{url.QueryEscape(key), url.QueryEscape(fmt.Sprint(fields[key])), true})
+			}
+			body = []byte(EncodeParams(form))
+			headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+	}
+
+	requestUri := path
+	if len(query) > 0 {
+		requestUri += "?" + EncodeParams(query)
+	}
+	return Request{Method: method, RequestUri: requestUri, Path: path, Query: EncodeParams(query),
+		ProtocolVersion: "HTTP/1.1", Headers: headers, Cookies: cookies, Body: body}
+}
+
+func (spec openApi) paramExample(param map[string]interface{}) interface{} {
+	if example, ok := param["example"]; ok {
+		return example
+	}
+	return spec.example(param["schema"], 0)
+}
+
+func (spec openApi) mediaExample(media map[string]interface{}) interface{} {
+	if example, ok := media["example"]; ok {
+		return example
+	}
+	return spec.example(media["schema"], 0)
+}
+
+// example returns the schema's example, default or first enum value, or else a placeholder of its type
+func (spec openApi) example(s interface{}, depth int) interface{} {
+	schema := spec.resolve(s)
+	for _, key := range []string{"example", "default"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		if schemas, ok := schema[key].([]interface{}); ok && len(schemas) > 0 && depth < maxSchemaDepth {
+			if key != "allOf" {
+				return spec.example(schemas[0], depth+1)
+			}
+			merged := map[string]interface{}{}
+			for _, sub := range schemas {
+				if fields, ok := spec.example(sub, depth+1).(map[string]interface{}); ok {
+					for k, v := range fields {
+						merged[k] = v
+					}
+				}
+			}
+			return merged
+		}
+	}
+
+	switch schema["type"] {
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		if depth >= maxSchemaDepth {
+			return []interface{}{}
+		}
+		return []interface{}{spec.example(schema["items"], depth+1)}
+	case "object":
+		return spec.objectExample(schema, depth)
+	}
+	if _, ok := schema["properties"]; ok {
+		return spec.objectExample(schema, depth)
+	}
+	return "test"
+}
+
+func (spec openApi) objectExample(schema map[string]interface{}, depth int) map[string]interface{} {
+	result := map[string]interface{}{}
+	if depth >= maxSchemaDepth {
+		return result
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	for name, prop := range props {
+		result[name] = spec.example(prop, depth+1)
+	}
+	return result
+}
+
+// resolve follows a local $ref, e.g. #/components/schemas/User
+func (spec openApi) resolve(v interface{}) map[string]interface{} {
+	obj, _ := v.(map[string]interface{})
+	for i := 0; i < maxSchemaDepth; i++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			break
+		}
+		var target interface{} = spec.doc
+		for _, step := range strings.Split(ref[2:], "/") {
+			step = strings.Replace(strings.Replace(step, "~1", "/", -1), "~0", "~", -1)
+			parent, _ := target.(map[string]interface{})
+			target = parent[step]
+		}
+		obj, _ = target.(map[string]interface{})
+	}
+	return obj
+}
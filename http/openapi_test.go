@@ -0,0 +1,61 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"testing"
+)
+
+func TestParseOpenApi(t *testing.T) {
+	spec, _ := os.ReadFile("../var/openapi/users.json")
+
+	got, err := ParseOpenApi(spec)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 5)
+
+	testutils.AssertEquals(t, got[0].Method, "POST")
+	testutils.AssertEquals(t, got[0].RequestUri, "/v1/login")
+	testutils.AssertTrue(t, got[0].HasFormUrlEncodedBody())
+	testutils.AssertByteEquals(t, got[0].Body, []byte("pass=test&user=admin"))
+
+	testutils.AssertEquals(t, got[1].Method, "GET")
+	testutils.AssertEquals(t, got[1].RequestUri, "/v1/users?limit=20")
+	testutils.AssertEquals(t, got[1].Query, "limit=20")
+	testutils.AssertEquals(t, len(got[1].Headers), 0)
+
+	testutils.AssertEquals(t, got[2].Method, "POST")
+	testutils.AssertEquals(t, got[2].RequestUri, "/v1/users")
+	testutils.AssertTrue(t, got[2].HasJsonBody())
+	var user struct {
+		Name    string
+		Age     int
+		Admin   bool
+		Tags    []string
+		Manager struct{ Name string }
+	}
+	testutils.AssertTrue(t, json.Unmarshal(got[2].Body, &user) == nil)
+	testutils.AssertEquals(t, user.Name, "foo")
+	testutils.AssertEquals(t, user.Age, 1)
+	testutils.AssertTrue(t, user.Admin)
+	testutils.AssertLen(t, user.Tags, 1)
+	testutils.AssertEquals(t, user.Manager.Name, "foo")
+
+	testutils.AssertEquals(t, got[3].Method, "GET")
+	testutils.AssertEquals(t, got[3].RequestUri, "/v1/users/1?fields=name")
+	testutils.AssertEquals(t, got[3].Path, "/v1/users/1")
+	testutils.AssertMapEquals(t, got[3].Headers, map[string]string{"X-Api-Key": "secret"})
+
+	testutils.AssertEquals(t, got[4].Method, "DELETE")
+	testutils.AssertEquals(t, got[4].RequestUri, "/v1/users/1")
+	testutils.AssertMapEquals(t, got[4].Cookies, map[string]string{"session": "test"})
+}
+
+func TestParseOpenApiRejectsOtherDocuments(t *testing.T) {
+	for _, doc := range []string{"not json", `{"swagger": "2.0", "paths": {}}`} {
+		_, err := ParseOpenApi([]byte(doc))
+
+		testutils.AssertTrue(t, err != nil)
+	}
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"strings"
+)
+
+type Param struct {
+	Key, Value string
+	HasValue   bool
+}
+
+func ParseParams(raw string) []Param {
+	params := []Param{}
+	if raw == "" {
+		return params
+	}
+	for _, p := range strings.Split(raw, "&") {
+		key, val, hasValue := strings.Cut(p, "=")
+		params = append(params, Param{key, val, hasValue})
+	}
+	return params
+}
+
+func EncodeParams(params []Param) string {
+	encoded := make([]string, len(params))
+	for i, p := range params {
+		encoded[i] = p.String()
+	}
+	return strings.Join(encoded, "&")
+}
+
+func (p Param) String() string {
+	if !p.HasValue {
+		return p.Key
+	}
+	return p.Key + "=" + p.Value
+}
+
+func (r Request) QueryParams() []Param {
+	return ParseParams(r.Query)
+}
+
+func (r Request) WithQueryParams(params []Param) Request {
+	return r.WithQuery(EncodeParams(params))
+}
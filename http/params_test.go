@@ -0,0 +1,50 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"reflect"
+	"testing"
+)
+
+func TestQueryParams(t *testing.T) {
+	cases := []struct {
+		query  string
+		params []Param
+	}{
+		{"", []Param{}},
+		{"foo=bar", []Param{{"foo", "bar", true}}},
+		{"foo=bar&baz=quix", []Param{{"foo", "bar", true}, {"baz", "quix", true}}},
+		{"foo=1&foo=2&bar=3", []Param{{"foo", "1", true}, {"foo", "2", true}, {"bar", "3", true}}},
+		{"foo=&bar", []Param{{"foo", "", true}, {"bar", "", false}}},
+		{"foo=a=b", []Param{{"foo", "a=b", true}}},
+	}
+
+	for _, c := range cases {
+		rq := Parse([]byte("GET /path?" + c.query + " HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+		got := rq.QueryParams()
+
+		if !reflect.DeepEqual(got, c.params) {
+			t.Errorf("got %v, wanted %v", got, c.params)
+		}
+	}
+}
+
+func TestEncodeParamsRoundTrip(t *testing.T) {
+	cases := []string{"", "foo=bar", "foo=1&foo=2", "foo=&bar", "foo&&bar=1", "a=%22b%22"}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, EncodeParams(ParseParams(c)), c)
+	}
+}
+
+func TestWithQueryParams(t *testing.T) {
+	rq := Parse([]byte("GET /path?foo=1&foo=2 HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	params := rq.QueryParams()
+	params[1].Value = "3"
+
+	got := rq.WithQueryParams(params)
+
+	testutils.AssertEquals(t, got.Query, "foo=1&foo=3")
+	testutils.AssertEquals(t, got.RequestUri, "/path?foo=1&foo=3")
+}
@@ -0,0 +1,17 @@
+package http
+
+import (
+	"golang.org/x/net/http/httpproxy"
+	"net/http"
+	"net/url"
+)
+
+// envProxy picks the proxy from HTTP_PROXY, HTTPS_PROXY and NO_PROXY (or their lowercase forms),
+// read when the transport is set up rather than once per process like http.ProxyFromEnvironment.
+// Loopback targets are never proxied.
+func envProxy() func(*http.Request) (*url.URL, error) {
+	proxy := httpproxy.FromEnvironment().ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
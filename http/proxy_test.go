@@ -0,0 +1,119 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func withEnv(t *testing.T, env map[string]string) {
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy", "REQUEST_METHOD"} {
+		t.Setenv(name, env[name])
+	}
+}
+
+func proxyServer(hits *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hits = append(*hits, r.URL.String())
+	}))
+}
+
+func TestShouldUseProxyFromEnvironment(t *testing.T) {
+	hits := []string{}
+	proxy := proxyServer(&hits)
+	defer proxy.Close()
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+	withEnv(t, map[string]string{"http_proxy": proxy.URL})
+	rq := Parse([]byte("GET /foo HTTP/1.1\r\nHost: target.test\r\n\r\n"))
+
+	SetupTransport(TransportOptions{Host: "http://target.test"})
+	res, err := rq.Send("http://target.test")
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, res.Code, 200)
+	testutils.AssertLen(t, hits, 1)
+	testutils.AssertEquals(t, hits[0], "http://target.test/foo")
+}
+
+func TestExplicitProxyWinsOverEnvironment(t *testing.T) {
+	envHits, flagHits := []string{}, []string{}
+	envProxy := proxyServer(&envHits)
+	defer envProxy.Close()
+	flagProxy := proxyServer(&flagHits)
+	defer flagProxy.Close()
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+	withEnv(t, map[string]string{"HTTP_PROXY": envProxy.URL})
+	rq := Parse([]byte("GET /foo HTTP/1.1\r\nHost: target.test\r\n\r\n"))
+
+	SetupTransport(TransportOptions{Host: "http://target.test", Proxy: flagProxy.URL})
+	_, err := rq.Send("http://target.test")
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, envHits, 0)
+	testutils.AssertLen(t, flagHits, 1)
+}
+
+func TestShouldIgnoreEnvironmentProxyWhenAsked(t *testing.T) {
+	withEnv(t, map[string]string{"HTTP_PROXY": "http://proxy.test:8080"})
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+
+	SetupTransport(TransportOptions{Host: "http://target.test", NoEnvProxy: true})
+
+	testutils.AssertTrue(t, http.DefaultTransport.(*http.Transport).Proxy == nil)
+}
+
+func TestEnvProxyPerScheme(t *testing.T) {
+	withEnv(t, map[string]string{"HTTP_PROXY": "proxy.test:8080", "HTTPS_PROXY": "https://secure.test:8443", "NO_PROXY": "internal.test"})
+	proxy := envProxy()
+
+	cases := []struct{ target, want string }{
+		{"http://example.com/", "http://proxy.test:8080"},
+		{"https://example.com/", "https://secure.test:8443"},
+		{"http://api.internal.test/", ""},
+		{"http://127.0.0.1:8080/", ""},
+	}
+
+	for _, c := range cases {
+		u, _ := url.Parse(c.target)
+		got, err := proxy(&http.Request{URL: u})
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, got == nil, c.want == "")
+		if got != nil {
+			testutils.AssertEquals(t, got.String(), c.want)
+		}
+	}
+}
+
+func TestNoProxyEntries(t *testing.T) {
+	withEnv(t, map[string]string{"HTTP_PROXY": "proxy.test:8080", "NO_PROXY": "example.com, .corp.test, 10.0.0.0/8, 192.168.1.1, api.test:8080"})
+	proxy := envProxy()
+
+	cases := []struct {
+		target string
+		bypass bool
+	}{
+		{"http://example.com/", true},
+		{"http://www.example.com/", true},
+		{"http://notexample.com/", false},
+		{"http://a.corp.test/", true},
+		{"http://10.1.2.3/", true},
+		{"http://11.1.2.3/", false},
+		{"http://192.168.1.1:8000/", true},
+		{"http://api.test:8080/", true},
+		{"http://api.test:9090/", false},
+		{"http://localhost:3000/", true},
+		{"http://[::1]/", true},
+		{"http://other.test/", false},
+	}
+
+	for _, c := range cases {
+		u, _ := url.Parse(c.target)
+		got, err := proxy(&http.Request{URL: u})
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, got == nil, c.bypass)
+	}
+}
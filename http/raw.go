@@ -0,0 +1,221 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"github.com/kamil-s-solecki/haze/utils"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var RawTimeout = 10 * time.Second
+
+func (r Request) WireBytes() []byte {
+	if r.Wire != nil {
+		return r.Wire
+	}
+	var buf bytes.Buffer
+	protocolVersion := r.ProtocolVersion
+	if protocolVersion == "" {
+		protocolVersion = "HTTP/1.1"
+	}
+	buf.WriteString(r.Method + " " + r.RequestUri + " " + protocolVersion + "\r\n")
+	r.writeHeaders(&buf)
+	buf.WriteString("\r\n")
+	buf.Write(r.Body)
+	return buf.Bytes()
+}
+
+// writeHeaders keeps the parsed header lines as they were, in their order and with duplicates,
+// e.g. two Content-Lengths. A changed header is written once, in place of its first line,
+// and the added ones follow in alphabetical order.
+func (r Request) writeHeaders(buf *bytes.Buffer) {
+	parsed := make(map[string]string, len(r.headerLines))
+	for _, line := range r.headerLines {
+		name, val := parseHeader([]byte(line.raw))
+		parsed[name] = val
+	}
+	parsedCookies := takeCookies(parsed)
+	cookiesKept := len(r.headerLines) > 0 && mapsEqual(parsedCookies, r.Cookies)
+
+	written := map[string]bool{}
+	for _, line := range r.headerLines {
+		if textproto.CanonicalMIMEHeaderKey(line.name) == "Cookie" {
+			if cookiesKept {
+				buf.WriteString(line.raw + "\r\n")
+			} else if !written["Cookie"] {
+				r.writeCookies(buf)
+			}
+			written["Cookie"] = true
+			continue
+		}
+		val, ok := r.Headers[line.name]
+		if !ok {
+			continue
+		}
+		if val == parsed[line.name] {
+			buf.WriteString(line.raw + "\r\n")
+		} else if !written[line.name] {
+			buf.WriteString(line.name + ": " + val + "\r\n")
+		}
+		written[line.name] = true
+	}
+	for _, key := range utils.SortedKeys(r.Headers) {
+		if !written[key] {
+			buf.WriteString(key + ": " + r.Headers[key] + "\r\n")
+		}
+	}
+	if !written["Cookie"] {
+		r.writeCookies(buf)
+	}
+}
+
+func (r Request) writeCookies(buf *bytes.Buffer) {
+	if len(r.Cookies) == 0 {
+		return
+	}
+	cookies := []string{}
+	for _, key := range utils.SortedKeys(r.Cookies) {
+		cookies = append(cookies, key+"="+r.Cookies[key])
+	}
+	buf.WriteString("Cookie: " + strings.Join(cookies, "; ") + "\r\n")
+}
+
+func mapsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Serialize is WireBytes of a well-formed request: the request line is filled in
+// and Content-Length matches the body, whatever the request file said.
+func (r Request) Serialize() []byte {
+	result := r.Clone()
+	if result.Method == "" {
+		result.Method = http.MethodGet
+	}
+	if result.RequestUri == "" {
+		result.RequestUri = "/"
+	}
+	if !r.streamsBody() {
+		key, ok := headerKey(result.Headers, "Content-Length")
+		if ok || len(r.Body) > 0 {
+			if !ok {
+				key = "Content-Length"
+			}
+			result.Headers[key] = strconv.Itoa(len(r.Body))
+		}
+	}
+	return result.WireBytes()
+}
+
+func (r Request) SendRaw(host string) (Response, error) {
+	defer globalHostLimiter.acquire(host)()
+	globalThrottle.wait()
+	start := time.Now()
+	conn, err := dialRaw(host)
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(RawTimeout))
+
+	if _, err := conn.Write(r.WireBytes()); err != nil {
+		return Response{}, err
+	}
+	if r.streamsBody() {
+		f, err := os.Open(r.BodyFile)
+		if err != nil {
+			return Response{}, err
+		}
+		_, err = io.Copy(conn, f)
+		f.Close()
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
+	reader := bufio.NewReader(conn)
+	res, err := readRawResponse(reader, r.Method)
+	for err == nil && isInformational(res.StatusCode) {
+		res, err = http.ReadResponse(reader, &http.Request{Method: r.Method})
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		globalThrottle.backOff(retryAfter(res))
+	}
+	response, err := toResponse(res)
+	response.Duration = time.Since(start)
+	return response, err
+}
+
+// readRawResponse treats a reply without a status line as an HTTP/0.9 simple response,
+// that is a 200 whose body lasts until the connection is closed.
+func readRawResponse(reader *bufio.Reader, method string) (*http.Response, error) {
+	statusPrefix := []byte("HTTP/")
+	head, _ := reader.Peek(len(statusPrefix))
+	if len(head) == 0 || bytes.Equal(head, statusPrefix) {
+		return http.ReadResponse(reader, &http.Request{Method: method})
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/0.9",
+		ProtoMinor:    9,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+func isInformational(code int) bool {
+	return code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+}
+
+func dialRaw(host string) (net.Conn, error) {
+	if socket, ok := unixSocket(host); ok {
+		return net.DialTimeout("unix", socket, RawTimeout)
+	}
+
+	target, err := ParseTarget(host)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RawTimeout)
+	defer cancel()
+	conn, err := dialContext(ctx, "tcp", target.Addr())
+	if err != nil || target.Scheme != "https" {
+		return conn, err
+	}
+
+	conf := tlsConfig.Clone()
+	conf.ServerName = target.Hostname
+	tlsConn := tls.Client(conn, conf)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,200 @@
+package http
+
+import (
+	"bufio"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func captureRaw(t *testing.T, size int) (host string, captured chan []byte) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	captured = make(chan []byte, 1)
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, size)
+		io.ReadFull(conn, buf)
+		captured <- buf
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+	}()
+	return "http://" + l.Addr().String(), captured
+}
+
+func TestShouldWriteWrongContentLengthVerbatim(t *testing.T) {
+	raw := []byte("POST /smuggle HTTP/1.1\r\nContent-Length: 100\r\nHost: localhost\r\n\r\nshort")
+	host, captured := captureRaw(t, len(raw))
+
+	res, err := Parse(raw).SendRaw(host)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, res.Code, 200)
+	testutils.AssertByteEquals(t, <-captured, raw)
+}
+
+func TestShouldWriteConflictingContentLengthAndTransferEncodingVerbatim(t *testing.T) {
+	raw := []byte("POST / HTTP/1.1\r\nContent-Length: 4\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\nG")
+	host, captured := captureRaw(t, len(raw))
+
+	_, err := Parse(raw).SendRaw(host)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertByteEquals(t, <-captured, raw)
+}
+
+func TestShouldWriteCookiesInRawRequest(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nCookie: b=2; a=1\r\nHost: localhost\r\n\r\n"))
+
+	testutils.AssertByteEquals(t, rq.WireBytes(), []byte("GET / HTTP/1.1\r\nCookie: b=2; a=1\r\nHost: localhost\r\n\r\n"))
+	testutils.AssertByteEquals(t, rq.WithCookie("c", "3").WireBytes(), []byte("GET / HTTP/1.1\r\nCookie: a=1; b=2; c=3\r\nHost: localhost\r\n\r\n"))
+}
+
+func TestShouldWriteHeadersInTheirOrderWithDuplicates(t *testing.T) {
+	raw := []byte("POST / HTTP/1.1\r\nHost: localhost\r\nX-B: 2\r\nContent-Length: 4\r\nX-A: 1\r\nContent-Length: 100\r\n\r\nGPOS")
+	host, captured := captureRaw(t, len(raw))
+
+	_, err := Parse(raw).SendRaw(host)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertByteEquals(t, <-captured, raw)
+}
+
+func TestShouldKeepHeaderOrderOfModifiedRequest(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nX-B: 2\r\nX-A: 1\r\nX-A: 0\r\n\r\n"))
+
+	got := rq.WithHeader("X-B", "3").WithHeader("X-A", "4").WithHeader("Accept", "*/*")
+
+	testutils.AssertByteEquals(t, got.WireBytes(), []byte("GET / HTTP/1.1\r\nHost: localhost\r\nX-B: 3\r\nX-A: 4\r\nAccept: */*\r\n\r\n"))
+}
+
+func TestShouldKeepInjectedCrlfOnTheWire(t *testing.T) {
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")).
+		WithHeader("X-Foo", "bar\r\nX-Injected: 1").
+		WithCookie("sid", "1\r\nX-Injected: 2")
+	want := []byte("GET / HTTP/1.1\r\nHost: localhost\r\nX-Foo: bar\r\nX-Injected: 1\r\nCookie: sid=1\r\nX-Injected: 2\r\n\r\n")
+	host, captured := captureRaw(t, len(want))
+
+	_, err := rq.SendRaw(host)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertByteEquals(t, <-captured, want)
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	cases := []struct {
+		raw, want string
+	}{
+		{"GET /foo HTTP/1.1\r\nHost: localhost\r\n\r\n", "GET /foo HTTP/1.1\r\nHost: localhost\r\n\r\n"},
+		{"GET /foo HTTP/1.1\r\nHost: localhost", "GET /foo HTTP/1.1\r\nHost: localhost\r\n\r\n"},
+		{"GET /foo HTTP/1.1\r\nHost: localhost\r\n", "GET /foo HTTP/1.1\r\nHost: localhost\r\n\r\n"},
+		{"POST /foo HTTP/1.1\nHost: localhost\n\na=1", "POST /foo HTTP/1.1\r\nHost: localhost\r\nContent-Length: 3\r\n\r\na=1"},
+		{"POST /foo HTTP/1.1\r\ncontent-length: 100\r\nHost: localhost\r\n\r\nshort", "POST /foo HTTP/1.1\r\ncontent-length: 5\r\nHost: localhost\r\n\r\nshort"},
+		{"GET /foo\r\nCookie: a=1\r\n\r\n", "GET /foo HTTP/1.1\r\nCookie: a=1\r\n\r\n"},
+		{"", "GET / HTTP/1.1\r\n\r\n"},
+	}
+
+	for _, c := range cases {
+		got := Parse([]byte(c.raw)).Serialize()
+
+		testutils.AssertEquals(t, string(got), c.want)
+		testutils.AssertEquals(t, string(Parse(got).Serialize()), c.want)
+	}
+}
+
+func TestShouldSanitizeContentLengthOnNormalPath(t *testing.T) {
+	gotLen := int64(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLen = r.ContentLength
+	}))
+	defer srv.Close()
+	rq := Parse([]byte("POST / HTTP/1.1\r\nContent-Length: 100\r\nHost: localhost\r\n\r\nshort"))
+
+	_, err := rq.Send(srv.URL)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, gotLen, int64(5))
+}
+
+func serveRaw(t *testing.T, response string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			for {
+				ln, err := reader.ReadString('\n')
+				if err != nil || ln == "\r\n" {
+					break
+				}
+			}
+			conn.Write([]byte(response))
+			conn.Close()
+		}
+	}()
+	return "http://" + l.Addr().String()
+}
+
+func TestShouldSkipInformationalResponses(t *testing.T) {
+	host := serveRaw(t, "HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 103 Early Hints\r\nLink: </style.css>\r\n\r\n"+
+		"HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	for _, send := range []func(string) (Response, error){rq.Send, rq.SendRaw} {
+		res, err := send(host)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, 200)
+		testutils.AssertEquals(t, res.Length, int64(2))
+		testutils.AssertByteEquals(t, res.Body(), []byte("ok"))
+	}
+}
+
+func TestShouldReadHttp09SimpleResponse(t *testing.T) {
+	cases := []string{"<html>hello</html>\n", "ok"}
+
+	for _, body := range cases {
+		host := serveRaw(t, body)
+		rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+		res, err := rq.SendRaw(host)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, 200)
+		testutils.AssertEquals(t, res.Length, int64(len(body)))
+		testutils.AssertByteEquals(t, res.Body(), []byte(body))
+	}
+}
+
+func TestShouldNotHangOnExpectContinue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+	SetupTransport(TransportOptions{Host: srv.URL})
+	rq := Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nExpect: 100-continue\r\nContent-Length: 5\r\n\r\nhello"))
+
+	res, err := rq.Send(srv.URL)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, res.Code, 200)
+	testutils.AssertByteEquals(t, res.Body(), []byte("hello"))
+}
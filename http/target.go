@@ -0,0 +1,132 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Target is the scheme and authority requests are sent to. Port is empty when not given explicitly.
+type Target struct {
+	Scheme   string
+	Hostname string
+	Port     string
+}
+
+var hostnameRegex = regexp.MustCompile("^[-a-zA-Z0-9.]{1,256}$")
+
+// ParseTarget splits a target like https://example.com:8443 into its parts. A target without a scheme
+// gets https when its port is 443 and http otherwise.
+func ParseTarget(host string) (Target, error) {
+	if !strings.Contains(host, "://") {
+		scheme := "http"
+		if strings.HasSuffix(strings.TrimSuffix(host, "/"), ":443") {
+			scheme = "https"
+		}
+		host = scheme + "://" + host
+	}
+
+	u, err := url.Parse(normalizeHost(host))
+	if err != nil {
+		return Target{}, fmt.Errorf("Invalid target '%v': %v", host, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Target{}, fmt.Errorf("Invalid target '%v': the scheme should be http or https", host)
+	}
+	if u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return Target{}, fmt.Errorf("Invalid target '%v': expected only protocol://hostname:port", host)
+	}
+	hostname := u.Hostname()
+	if net.ParseIP(hostname) == nil && !hostnameRegex.MatchString(hostname) {
+		return Target{}, fmt.Errorf("Invalid target '%v': bad hostname '%v'", host, hostname)
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return Target{}, fmt.Errorf("Invalid target '%v': bad port '%v'", host, port)
+		}
+	} else if strings.HasSuffix(u.Host, ":") {
+		return Target{}, fmt.Errorf("Invalid target '%v': empty port", host)
+	}
+	return Target{Scheme: u.Scheme, Hostname: hostname, Port: u.Port()}, nil
+}
+
+// Addr is the host:port to dial, with the scheme's default port filled in
+func (t Target) Addr() string {
+	port := t.Port
+	if port == "" {
+		port = "80"
+		if t.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(t.Hostname, port)
+}
+
+func (t Target) String() string {
+	authority := t.Hostname
+	if strings.Contains(authority, ":") {
+		authority = "[" + authority + "]"
+	}
+	if t.Port != "" {
+		authority += ":" + t.Port
+	}
+	return t.Scheme + "://" + authority
+}
+
+// ImpliedScheme is the scheme the port is well known for: https for 443 and http for 80.
+// It is empty for any other port.
+func (t Target) ImpliedScheme() string {
+	return impliedScheme(t.Port)
+}
+
+func impliedScheme(port string) string {
+	switch port {
+	case "443":
+		return "https"
+	case "80":
+		return "http"
+	}
+	return ""
+}
+
+// ImpliedScheme guesses the scheme the request was captured with, from an absolute request-uri
+// or the port in the Host header. It is empty when nothing hints at it.
+func (r Request) ImpliedScheme() string {
+	if prefix, _ := splitAbsoluteUri(r.RequestUri); prefix != "" {
+		return strings.ToLower(prefix[:strings.Index(prefix, ":")])
+	}
+	host, ok := r.Header("Host")
+	if !ok {
+		return ""
+	}
+	_, port, err := net.SplitHostPort(strings.TrimSpace(host))
+	if err != nil {
+		return ""
+	}
+	return impliedScheme(port)
+}
+
+// ExplainSchemeError adds a hint to the baffling errors of speaking TLS to a plain http server,
+// or plain http to a TLS one
+func ExplainSchemeError(err error, host string) error {
+	target, e := ParseTarget(host)
+	if err == nil || e != nil {
+		return err
+	}
+	msg := err.Error()
+	other := target
+	if target.Scheme == "https" && (strings.Contains(msg, "server gave HTTP response to HTTPS client") ||
+		strings.Contains(msg, "first record does not look like a TLS handshake")) {
+		other.Scheme = "http"
+		return fmt.Errorf("%w\nThe target does not seem to speak TLS, try %v", err, other)
+	}
+	if target.Scheme == "http" && (strings.Contains(msg, `malformed HTTP response "\x15\x03`) ||
+		strings.Contains(msg, "connection reset by peer") && target.ImpliedScheme() != "http") {
+		other.Scheme = "https"
+		return fmt.Errorf("%w\nThe target may expect TLS, try %v", err, other)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"errors"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	cases := []struct {
+		host, scheme, hostname, port, addr, str string
+	}{
+		{"http://example.com", "http", "example.com", "", "example.com:80", "http://example.com"},
+		{"https://example.com/", "https", "example.com", "", "example.com:443", "https://example.com"},
+		{"http://127.0.0.1:8080", "http", "127.0.0.1", "8080", "127.0.0.1:8080", "http://127.0.0.1:8080"},
+		{"https://[2001:db8::1]:8443", "https", "2001:db8::1", "8443", "[2001:db8::1]:8443", "https://[2001:db8::1]:8443"},
+		{"http://::1", "http", "::1", "", "[::1]:80", "http://[::1]"},
+		{"example.com", "http", "example.com", "", "example.com:80", "http://example.com"},
+		{"example.com:8443", "http", "example.com", "8443", "example.com:8443", "http://example.com:8443"},
+		{"example.com:443", "https", "example.com", "443", "example.com:443", "https://example.com:443"},
+		{"localhost:443/", "https", "localhost", "443", "localhost:443", "https://localhost:443"},
+	}
+
+	for _, c := range cases {
+		target, err := ParseTarget(c.host)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, target.Scheme, c.scheme)
+		testutils.AssertEquals(t, target.Hostname, c.hostname)
+		testutils.AssertEquals(t, target.Port, c.port)
+		testutils.AssertEquals(t, target.Addr(), c.addr)
+		testutils.AssertEquals(t, target.String(), c.str)
+	}
+}
+
+func TestParseTargetRejectsInvalidTargets(t *testing.T) {
+	for _, host := range []string{
+		"", "ftp://example.com", "http://", "http://exa mple.com", "http://example.com:99999",
+		"http://example.com:0", "http://example.com:", "http://example.com:abc", "http://example.com/api",
+		"http://example.com?a=1", "http://user@example.com", "unix:/tmp/app.sock",
+	} {
+		_, err := ParseTarget(host)
+
+		testutils.AssertTrue(t, err != nil)
+	}
+}
+
+func TestShouldSendToSchemelessTarget(t *testing.T) {
+	rq := Parse([]byte("GET /foo HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+	req := rq.asHttpReq("example.com:8080")
+
+	testutils.AssertEquals(t, req.URL.String(), "http://example.com:8080/foo")
+}
+
+func TestTargetImpliedScheme(t *testing.T) {
+	cases := []struct{ host, implied string }{
+		{"http://example.com:443", "https"},
+		{"https://example.com:80", "http"},
+		{"https://example.com:443", "https"},
+		{"http://example.com", ""},
+		{"http://example.com:8443", ""},
+	}
+
+	for _, c := range cases {
+		target, _ := ParseTarget(c.host)
+
+		testutils.AssertEquals(t, target.ImpliedScheme(), c.implied)
+	}
+}
+
+func TestRequestImpliedScheme(t *testing.T) {
+	cases := []struct{ raw, implied string }{
+		{"GET / HTTP/1.1\r\nHost: example.com:443\r\n\r\n", "https"},
+		{"GET / HTTP/1.1\r\nHost: example.com:80\r\n\r\n", "http"},
+		{"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", ""},
+		{"GET / HTTP/1.1\r\nHost: example.com:8080\r\n\r\n", ""},
+		{"GET HTTPS://example.com/foo HTTP/1.1\r\nHost: example.com\r\n\r\n", "https"},
+		{"GET /foo HTTP/1.1\r\n\r\n", ""},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, Parse([]byte(c.raw)).ImpliedScheme(), c.implied)
+	}
+}
+
+func TestExplainTlsToPlainHttpServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	defer func(tr http.RoundTripper) { http.DefaultTransport = tr }(http.DefaultTransport)
+	host := strings.Replace(srv.URL, "http://", "https://", 1)
+	SetupTransport(TransportOptions{Host: host})
+
+	_, err := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")).Send(host)
+	explained := ExplainSchemeError(err, host)
+
+	testutils.AssertTrue(t, err != nil)
+	testutils.AssertTrue(t, errors.Is(explained, err))
+	testutils.AssertTrue(t, strings.HasSuffix(explained.Error(), "The target does not seem to speak TLS, try "+srv.URL))
+}
+
+func TestExplainPlainHttpToTlsServer(t *testing.T) {
+	err := errors.New(`net/http: HTTP/1.x transport connection broken: malformed HTTP response "\x15\x03\x01\x00\x02\x02"`)
+
+	explained := ExplainSchemeError(err, "http://example.com:8443")
+
+	testutils.AssertTrue(t, strings.HasSuffix(explained.Error(), "The target may expect TLS, try https://example.com:8443"))
+	testutils.AssertTrue(t, ExplainSchemeError(err, "https://example.com:8443") == err)
+	testutils.AssertTrue(t, ExplainSchemeError(nil, "http://example.com") == nil)
+}
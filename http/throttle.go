@@ -0,0 +1,63 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/logging"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBackoff is used when a 429 response carries no usable Retry-After header.
+var DefaultBackoff = 5 * time.Second
+
+// MaxBackoff caps the Retry-After of the target, so a server cannot stall the run for hours.
+var MaxBackoff = time.Minute
+
+type throttle struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+var globalThrottle = &throttle{}
+
+func (t *throttle) wait() {
+	t.mu.Lock()
+	until := t.until
+	t.mu.Unlock()
+
+	if d := time.Until(until); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (t *throttle) backOff(d time.Duration) {
+	defer t.mu.Unlock()
+	t.mu.Lock()
+
+	if until := time.Now().Add(d); until.After(t.until) {
+		logging.Infof("throttled by the target, backing off for %v", d.Round(time.Millisecond))
+		t.until = until
+	}
+}
+
+func retryAfter(res *http.Response) time.Duration {
+	if d := serverRetryAfter(res); d < MaxBackoff {
+		return d
+	}
+	return MaxBackoff
+}
+
+func serverRetryAfter(res *http.Response) time.Duration {
+	val := res.Header.Get("Retry-After")
+	if val == "" {
+		return DefaultBackoff
+	}
+	if secs, err := strconv.Atoi(val); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if date, err := http.ParseTime(val); err == nil {
+		return time.Until(date)
+	}
+	return DefaultBackoff
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWaitForRetryAfterOn429(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	defer func() { globalThrottle = &throttle{} }()
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	first, _ := rq.Send(srv.URL)
+	start := time.Now()
+	second, _ := rq.Send(srv.URL)
+	waited := time.Since(start)
+
+	testutils.AssertEquals(t, first.Code, 429)
+	testutils.AssertEquals(t, second.Code, 200)
+	testutils.AssertTrue(t, waited >= 900*time.Millisecond)
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", DefaultBackoff},
+		{"3", 3 * time.Second},
+		{"foo", DefaultBackoff},
+		{"86400", MaxBackoff},
+		{time.Now().Add(48 * time.Hour).UTC().Format(http.TimeFormat), MaxBackoff},
+	}
+
+	for _, c := range cases {
+		res := &http.Response{Header: http.Header{}}
+		if c.header != "" {
+			res.Header.Set("Retry-After", c.header)
+		}
+
+		testutils.AssertEquals(t, retryAfter(res), c.want)
+	}
+}
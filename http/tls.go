@@ -0,0 +1,67 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsConfig = &tls.Config{InsecureSkipVerify: true}
+
+// ParseTLSVersion turns e.g. "1.2" into tls.VersionTLS12. An empty string means the Go default.
+func ParseTLSVersion(name string) (uint16, error) {
+	if name == "" {
+		return 0, nil
+	}
+	version, ok := tlsVersions[name]
+	if !ok {
+		return 0, fmt.Errorf("Invalid TLS version: '%v'. Possible values: 1.0, 1.1, 1.2, 1.3", name)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites turns a comma-separated list of IANA names, e.g. TLS_RSA_WITH_AES_128_CBC_SHA,
+// into cipher suite ids. Insecure suites are accepted, so that weak configurations can be probed.
+func ParseCipherSuites(list string) ([]uint16, error) {
+	if list == "" {
+		return nil, nil
+	}
+	ids := map[string]uint16{}
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids[suite.Name] = suite.ID
+	}
+
+	result := []uint16{}
+	for _, name := range strings.Split(list, ",") {
+		id, ok := ids[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown cipher suite: '%v'", name)
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
+
+func newTLSConfig(opts TransportOptions) (*tls.Config, error) {
+	conf := &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         opts.MinTLS,
+		MaxVersion:         opts.MaxTLS,
+		CipherSuites:       opts.CipherSuites,
+	}
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot load the client certificate: %v", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	cases := []struct {
+		name    string
+		version uint16
+		ok      bool
+	}{
+		{"", 0, true},
+		{"1.0", tls.VersionTLS10, true},
+		{"1.2", tls.VersionTLS12, true},
+		{"1.3", tls.VersionTLS13, true},
+		{"1.4", 0, false},
+		{"tls1.2", 0, false},
+	}
+
+	for _, c := range cases {
+		version, err := ParseTLSVersion(c.name)
+
+		testutils.AssertEquals(t, version, c.version)
+		testutils.AssertEquals(t, err == nil, c.ok)
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	got, err := ParseCipherSuites("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_RSA_WITH_RC4_128_SHA")
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0], tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256)
+	testutils.AssertEquals(t, got[1], tls.TLS_RSA_WITH_RC4_128_SHA)
+
+	_, err = ParseCipherSuites("TLS_NOPE")
+
+	testutils.AssertTrue(t, err != nil)
+}
+
+func TestShouldRejectServerBelowMinTLSVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+	defer func(tr http.RoundTripper, conf *tls.Config) {
+		http.DefaultTransport = tr
+		tlsConfig = conf
+	}(http.DefaultTransport, tlsConfig)
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	SetupTransport(TransportOptions{Host: srv.URL})
+	for _, send := range []func(string) (Response, error){rq.Send, rq.SendRaw} {
+		res, err := send(srv.URL)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, 200)
+	}
+
+	SetupTransport(TransportOptions{Host: srv.URL, MinTLS: tls.VersionTLS13})
+	for _, send := range []func(string) (Response, error){rq.Send, rq.SendRaw} {
+		_, err := send(srv.URL)
+
+		testutils.AssertTrue(t, err != nil)
+	}
+}
+
+func writeClientCert(t *testing.T) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return
+}
+
+func TestShouldPresentClientCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+	defer func(tr http.RoundTripper, conf *tls.Config) {
+		http.DefaultTransport = tr
+		tlsConfig = conf
+	}(http.DefaultTransport, tlsConfig)
+	certFile, keyFile := writeClientCert(t)
+	rq := Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	SetupTransport(TransportOptions{Host: srv.URL})
+	_, err := rq.Send(srv.URL)
+
+	testutils.AssertTrue(t, err != nil)
+
+	err = SetupTransport(TransportOptions{Host: srv.URL, ClientCert: certFile, ClientKey: keyFile})
+	testutils.AssertTrue(t, err == nil)
+	for _, send := range []func(string) (Response, error){rq.Send, rq.SendRaw} {
+		res, err := send(srv.URL)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, 200)
+	}
+}
+
+func TestShouldFailOnUnreadableClientCertificate(t *testing.T) {
+	certFile, _ := writeClientCert(t)
+
+	err := SetupTransport(TransportOptions{ClientCert: certFile, ClientKey: filepath.Join(t.TempDir(), "missing.key")})
+
+	testutils.AssertTrue(t, err != nil)
+}
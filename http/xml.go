@@ -0,0 +1,134 @@
+package http
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+)
+
+type XmlValue struct {
+	Path, Value string
+	start, end  int
+}
+
+var xmlAttr = regexp.MustCompile(`\s([^\s=/>]+)\s*=\s*("[^"]*"|'[^']*')`)
+
+func (r Request) HasXmlBody() bool {
+	ct, ok := r.Header("Content-Type")
+	if !ok {
+		return r.sniffBody() == sniffedXml
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
+
+func isXml(body []byte) bool {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	elements := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return elements > 0
+		}
+		if err != nil {
+			return false
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			elements++
+		}
+	}
+}
+
+func (r Request) XmlValues() []XmlValue {
+	values := []XmlValue{}
+	dec := xml.NewDecoder(bytes.NewReader(r.Body))
+	dec.Strict = false
+
+	type element struct {
+		path     string
+		children map[string]int
+		hasText  bool
+	}
+	stack := []*element{{children: map[string]int{}}}
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.RawToken()
+		if err != nil {
+			break
+		}
+		end := int(dec.InputOffset())
+		parent := stack[len(stack)-1]
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := xmlName(t.Name)
+			if n := parent.children[name]; n > 0 {
+				parent.children[name]++
+				name = fmt.Sprintf("%v[%v]", name, n)
+			} else {
+				parent.children[name] = 1
+			}
+			el := &element{path: strings.TrimPrefix(parent.path+"/"+name, "/"), children: map[string]int{}}
+			values = append(values, xmlAttrValues(r.Body, el.path, t, start, end)...)
+			stack = append(stack, el)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			raw := r.Body[start:end]
+			trimmed := bytes.TrimSpace(raw)
+			if len(trimmed) == 0 || len(stack) == 1 || parent.hasText {
+				continue
+			}
+			parent.hasText = true
+			s := start + bytes.Index(raw, trimmed)
+			values = append(values, XmlValue{parent.path, strings.TrimSpace(string(t)), s, s + len(trimmed)})
+		}
+	}
+	return values
+}
+
+func xmlAttrValues(body []byte, path string, el xml.StartElement, start, end int) []XmlValue {
+	values := []XmlValue{}
+	tag := body[start:end]
+	for _, m := range xmlAttr.FindAllSubmatchIndex(tag, -1) {
+		name := string(tag[m[2]:m[3]])
+		for _, a := range el.Attr {
+			if xmlName(a.Name) == name {
+				values = append(values, XmlValue{path + "/@" + name, a.Value, start + m[4] + 1, start + m[5] - 1})
+				break
+			}
+		}
+	}
+	return values
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// WithXmlValue inserts val as is, so that entities and markup (e.g. XXE payloads) are not escaped.
+func (r Request) WithXmlValue(path, val string) Request {
+	for _, v := range r.XmlValues() {
+		if v.Path != path {
+			continue
+		}
+		var body bytes.Buffer
+		body.Write(r.Body[:v.start])
+		io.WriteString(&body, val)
+		body.Write(r.Body[v.end:])
+		return r.withFixedBody(body.Bytes())
+	}
+	return r.Clone()
+}
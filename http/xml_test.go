@@ -0,0 +1,72 @@
+package http
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func xmlRequest(contentType, body string) Request {
+	return Parse([]byte("POST /api HTTP/1.1\r\nContent-Type: " + contentType + "\r\n\r\n" + body))
+}
+
+func TestHasXmlBody(t *testing.T) {
+	cases := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/xml", true},
+		{"text/xml; charset=utf-8", true},
+		{"application/soap+xml", true},
+		{"application/json", false},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, xmlRequest(c.contentType, "<a/>").HasXmlBody(), c.want)
+	}
+}
+
+func TestXmlValues(t *testing.T) {
+	rq := xmlRequest("application/xml", `<?xml version="1.0"?><user id="7"><name> bob </name><tag>a</tag><tag>b</tag></user>`)
+
+	got := rq.XmlValues()
+
+	testutils.AssertLen(t, got, 4)
+	testutils.AssertEquals(t, got[0].Path, "user/@id")
+	testutils.AssertEquals(t, got[0].Value, "7")
+	testutils.AssertEquals(t, got[1].Path, "user/name")
+	testutils.AssertEquals(t, got[1].Value, "bob")
+	testutils.AssertEquals(t, got[3].Path, "user/tag[1]")
+	testutils.AssertEquals(t, got[3].Value, "b")
+}
+
+func TestWithXmlValueElementText(t *testing.T) {
+	rq := xmlRequest("application/xml", "<user><name> bob </name></user>")
+
+	got := rq.WithXmlValue("user/name", "&xxe;")
+
+	testutils.AssertEquals(t, string(got.Body), "<user><name> &xxe; </name></user>")
+	testutils.AssertEquals(t, string(rq.Body), "<user><name> bob </name></user>")
+}
+
+func TestWithXmlValueAttribute(t *testing.T) {
+	rq := xmlRequest("text/xml", `<user id='7' role="guest"/>`)
+
+	got := rq.WithXmlValue("user/@role", "admin")
+
+	testutils.AssertEquals(t, string(got.Body), `<user id='7' role="admin"/>`)
+}
+
+func TestWithXmlValueUpdatesContentLength(t *testing.T) {
+	rq := Parse([]byte("POST / HTTP/1.1\r\nContent-Type: text/xml\r\nContent-Length: 9\r\n\r\n<a>xy</a>"))
+
+	got := rq.WithXmlValue("a", "xyz")
+
+	testutils.AssertEquals(t, got.Headers["Content-Length"], "10")
+	testutils.AssertEquals(t, string(got.Body), "<a>xyz</a>")
+}
+
+func TestWithXmlValueUnknownPath(t *testing.T) {
+	rq := xmlRequest("application/xml", "<a>x</a>")
+
+	testutils.AssertEquals(t, string(rq.WithXmlValue("b", "y").Body), "<a>x</a>")
+}
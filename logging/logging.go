@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+type Level int
+
+const (
+	Error Level = iota
+	Warn
+	Info
+	Debug
+)
+
+var levelNames = []string{"error", "warn", "info", "debug"}
+
+var prefixes = []string{"ERROR", "WARNING", "INFO", "DEBUG"}
+
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{w: w, level: level}
+}
+
+// std writes to stderr, so that the log does not mix with the results on stdout
+var std = New(os.Stderr, Warn)
+
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return Warn, fmt.Errorf("Invalid log level: '%v'. Possible values: %v", name, strings.Join(levelNames, ", "))
+}
+
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+func (l *Logger) Logf(level Level, format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level > l.level {
+		return
+	}
+	fmt.Fprintf(l.w, prefixes[level]+": "+format+"\n", a...)
+}
+
+func SetLevel(level Level) {
+	std.SetLevel(level)
+}
+
+func Errorf(format string, a ...any) {
+	std.Logf(Error, format, a...)
+}
+
+func Warnf(format string, a ...any) {
+	std.Logf(Warn, format, a...)
+}
+
+func Infof(format string, a ...any) {
+	std.Logf(Info, format, a...)
+}
+
+func Debugf(format string, a ...any) {
+	std.Logf(Debug, format, a...)
+}
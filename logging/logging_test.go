@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func logAll(l *Logger) {
+	l.Logf(Error, "e %v", 1)
+	l.Logf(Warn, "w %v", 2)
+	l.Logf(Info, "i %v", 3)
+	l.Logf(Debug, "d %v", 4)
+}
+
+func TestLevelSuppressesLowerPriorityMessages(t *testing.T) {
+	cases := []struct {
+		level Level
+		want  string
+	}{
+		{Error, "ERROR: e 1\n"},
+		{Warn, "ERROR: e 1\nWARNING: w 2\n"},
+		{Info, "ERROR: e 1\nWARNING: w 2\nINFO: i 3\n"},
+		{Debug, "ERROR: e 1\nWARNING: w 2\nINFO: i 3\nDEBUG: d 4\n"},
+	}
+
+	for _, c := range cases {
+		out := &bytes.Buffer{}
+		logAll(New(out, c.level))
+
+		testutils.AssertEquals(t, out.String(), c.want)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := New(out, Error)
+
+	l.SetLevel(Info)
+	logAll(l)
+
+	testutils.AssertEquals(t, out.String(), "ERROR: e 1\nWARNING: w 2\nINFO: i 3\n")
+}
+
+func TestParseLevel(t *testing.T) {
+	for i, name := range []string{"error", "warn", "info", "debug"} {
+		level, err := ParseLevel(name)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, level, Level(i))
+	}
+	_, err := ParseLevel("trace")
+	testutils.AssertTrue(t, err != nil)
+}
@@ -1,51 +1,268 @@
 package main
 
 import (
+	"fmt"
+	"math/rand"
 	"os"
+	"os/signal"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 	"github.com/kamil-s-solecki/haze/cliargs"
 	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/logging"
 	"github.com/kamil-s-solecki/haze/mutable"
 	"github.com/kamil-s-solecki/haze/mutation"
+	"github.com/kamil-s-solecki/haze/notify"
 	"github.com/kamil-s-solecki/haze/report"
 	"github.com/kamil-s-solecki/haze/reportable"
+	"github.com/kamil-s-solecki/haze/summary"
+	"github.com/kamil-s-solecki/haze/template"
 	"github.com/kamil-s-solecki/haze/workerpool"
 	"github.com/kamil-s-solecki/haze/tui"
 )
 
 var atui tui.Tui
+var sarif *report.Sarif
+var httpFile *report.HttpFile
+var markdown *report.Markdown
+var notifier *notify.Notifier
+var sharedThreads workerpool.Slots
 
 func main() {
 	atui = tui.Create()
-	atui.PrintBanner()
 	args := cliargs.ParseArgs()
-	http.SetupTransport(args.Proxy)
+	logLevel, _ := logging.ParseLevel(args.LogLevel)
+	logging.SetLevel(logLevel)
+	atui.Configure(args)
+	atui.PrintBanner()
+	if err := http.SetupTransport(transportOptions(args)); err != nil {
+		atui.Fatal(err)
+	}
+	http.MaxBodyBytes = int64(args.MaxBody)
+	http.MaxPerHost = args.HostThreads
+	mutable.AllowCrlf = args.Crlf
+
+	if args.Replay != "" {
+		replay(args)
+		return
+	}
+
+	iterations := templateIterations(args)
+	planned := plannedRequests(args, iterations)
+	if args.CountOnly {
+		atui.Count(planned)
+		return
+	}
 
 	reportDir := ""
-	if !args.ProbeOnly {
+	if !args.ProbeOnly && !args.DryRun {
 		reportDir = report.MakeReportDir(args.OutputDir)
+		markdown = report.NewMarkdown()
+	}
+	atui.PrintInfo(args, reportDir, planned)
+
+	if args.Sarif != "" {
+		sarif = report.NewSarif()
+	}
+
+	if args.HttpFile != "" {
+		httpFile = report.NewHttpFile()
 	}
-	atui.PrintInfo(args, reportDir)
-	
+
+	if args.Webhook != "" {
+		var err error
+		notifier, err = notify.Start(args.Webhook, time.Duration(args.WebhookEvery)*time.Second)
+		if err != nil {
+			atui.Fatal(err)
+		}
+	}
+
+	stats := summary.Start()
+	quota := workerpool.NewQuota(args.MaxRequests)
+	defer interruptOnSignal(stats, quota)()
+	fuzzAll(args, iterations, reportDir, stats, quota)
+
+	if !args.ProbeOnly && !args.DryRun {
+		atui.PrintResults()
+		atui.PrintSummary(stats)
+	}
+
+	if markdown != nil {
+		target := args.Host
+		if args.TargetsFile != "" {
+			target = "the hosts in " + args.TargetsFile
+		}
+		run := report.Run{Target: target, Command: cliargs.RedactedCommand(append([]string{"haze"}, os.Args[1:]...)), Stats: stats}
+		if err := markdown.Write(path.Join(reportDir, "summary.md"), run); err != nil {
+			atui.Error(err)
+		}
+	}
+
+	if sarif != nil {
+		if err := sarif.Write(args.Sarif); err != nil {
+			atui.Error(err)
+		}
+	}
+
+	if httpFile != nil {
+		if err := httpFile.Write(args.HttpFile); err != nil {
+			atui.Error(err)
+		}
+	}
+
+	if notifier != nil {
+		if err := notifier.Close(); err != nil {
+			atui.Error(err)
+		}
+	}
+}
+
+// interruptOnSignal stops the run on the first Ctrl-C: the requests in flight are finished and
+// the results written as usual. The second one exits right away. The returned func uninstalls it.
+func interruptOnSignal(stats *summary.Summary, quota *workerpool.Quota) func() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		logging.Warnf("interrupted, finishing the requests in flight. Press Ctrl-C again to exit right away")
+		stats.Interrupt()
+		quota.Stop()
+		select {
+		case <-signals:
+			report.RemovePartial()
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// fuzzAll sends the requests of every file to every target. The targets of a -targets-file are
+// fuzzed side by side, so that a slow host does not hold up the others.
+func fuzzAll(args cliargs.Args, iterations []map[string]string, reportDir string, stats *summary.Summary, quota *workerpool.Quota) {
+	if args.TargetsFile == "" {
+		fuzzTarget(args, args, iterations, reportDir, stats, quota)
+		return
+	}
+	sharedThreads = workerpool.NewSlots(args.Threads)
+	defer func() { sharedThreads = nil }()
+	var wg sync.WaitGroup
+	for _, targetArgs := range perTarget(args) {
+		wg.Add(1)
+		go func(targetArgs cliargs.Args) {
+			defer wg.Done()
+			fuzzTarget(args, targetArgs, iterations, reportDir, stats, quota)
+		}(targetArgs)
+	}
+	wg.Wait()
+}
+
+func fuzzTarget(args, targetArgs cliargs.Args, iterations []map[string]string, reportDir string, stats *summary.Summary, quota *workerpool.Quota) {
 	for _, rfile := range args.RequestFiles {
+		if quota.Exhausted() {
+			break
+		}
 		atui.FuzzNewFile(rfile)
-		for _, rq := range parseRequestsFromFile(rfile, args) {
+		for _, rq := range parseRequestsFromFile(rfile, targetArgs, iterations) {
+			if quota.Exhausted() {
+				break
+			}
+			if args.TargetsFile != "" {
+				rq.Target = targetArgs.Host
+			}
 			atui.FuzzNewRequest(rq)
-			probe(rq, args.Host)
+			rqArgs := withTarget(targetArgs, rq)
+			warnSchemeMismatch(rqArgs, rq)
+			if args.DryRun {
+				dryRun(rqArgs, rq)
+				continue
+			}
+			baseline, err := probe(rq, rqArgs)
+			if err != nil && args.TargetsFile != "" {
+				atui.Error(fmt.Errorf("skipping %v: %v", targetArgs.Host, err))
+				return
+			} else if err != nil {
+				atui.Fatal(err)
+			}
 			if args.ProbeOnly {
 				atui.EmptyLine()
 			} else {
-				fuzz(args, rq, reportDir)
+				fuzz(rqArgs, rq, baseline, reportDir, stats, quota)
 			}
 		}
 	}
 }
 
-func parseRequestsFromFile(rfile string, args cliargs.Args) (result []http.Request) {
+func transportOptions(args cliargs.Args) http.TransportOptions {
+	opts := http.TransportOptions{Host: args.Host, Proxy: args.Proxy, NoEnvProxy: args.NoEnvProxy, ClientCert: args.ClientCert, ClientKey: args.ClientKey,
+		DnsCacheTTL: time.Duration(args.DnsTTL) * time.Second, NoKeepAlive: args.NoKeepAlive}
+	if args.Http1 {
+		opts.Protocol = http.Http1
+	} else if args.Http2 {
+		opts.Protocol = http.Http2
+	}
+	opts.MinTLS, _ = http.ParseTLSVersion(args.TLSMin)
+	opts.MaxTLS, _ = http.ParseTLSVersion(args.TLSMax)
+	opts.CipherSuites, _ = http.ParseCipherSuites(args.Ciphers)
+	return opts
+}
+
+func templateIterations(args cliargs.Args) []map[string]string {
+	vars, _ := template.ParseVars(args.Vars)
+	var dataset []map[string]string
+	if args.VarsFile != "" {
+		var err error
+		dataset, err = template.ReadDataset(args.VarsFile)
+		if err != nil {
+			atui.Fatal(err)
+		}
+	}
+	return template.Iterations(vars, dataset)
+}
+
+// parseRendered fixes the Content-Length after the placeholders of the body are rendered, unless
+// the request file had a wrong one on purpose
+func parseRendered(raw, rendered []byte) http.Request {
+	rq := http.Parse(rendered)
+	tpl := http.Parse(raw)
+	if cl, ok := tpl.Header("Content-Length"); ok && cl == strconv.Itoa(len(tpl.Body)) {
+		return rq.WithContentLength()
+	}
+	return rq
+}
+
+func parseRequestsFromFile(rfile string, args cliargs.Args, iterations []map[string]string) (result []http.Request) {
 	raw := readRawRequest(rfile)
-	if !args.Har {
-		result = []http.Request{http.Parse(raw)}
-	} else {
-		result = http.ParseHar(raw, args.Host)
+	for _, vars := range iterations {
+		rendered := template.Render(raw, vars, args.EnvVars)
+		if args.Har {
+			result = append(result, http.ParseHar(rendered, args.Host)...)
+		} else if args.Burp {
+			rqs, err := http.ParseBurp(rendered, args.Host)
+			if err != nil {
+				atui.Fatal(err)
+			}
+			result = append(result, rqs...)
+		} else if args.OpenApi {
+			rqs, err := http.ParseOpenApi(rendered)
+			if err != nil {
+				atui.Fatal(err)
+			}
+			result = append(result, rqs...)
+		} else {
+			result = append(result, parseRendered(raw, rendered))
+		}
 	}
 
 	if args.Cookies != "" {
@@ -56,9 +273,71 @@ func parseRequestsFromFile(rfile string, args cliargs.Args) (result []http.Reque
 		result = overwriteHeaders(result, args)
 	}
 
+	if args.BodyFile != "" {
+		result = withBodyFile(result, args)
+	}
+
+	if args.Identity {
+		for i := range result {
+			result[i] = result[i].WithIdentityEncoding()
+		}
+	}
+
+	if args.StripHop && !args.Raw {
+		for i := range result {
+			result[i] = result[i].WithoutHopByHopHeaders()
+		}
+	}
+
+	if args.NoKeepAlive {
+		for i := range result {
+			result[i] = result[i].WithConnectionClose()
+		}
+	}
+
+	if args.Methods != "" {
+		result = withMethods(result, args)
+	}
+
 	return
 }
 
+// perTarget gives the arguments for each host of the -targets-file, which share the threads
+// and -max-requests
+func perTarget(args cliargs.Args) []cliargs.Args {
+	if args.TargetsFile == "" {
+		return []cliargs.Args{args}
+	}
+	targets, _ := cliargs.ReadTargets(args.TargetsFile)
+	result := []cliargs.Args{}
+	for _, target := range targets {
+		targetArgs := args
+		targetArgs.Host = target
+		result = append(result, targetArgs)
+	}
+	return result
+}
+
+// withTarget sends the request to the host it was captured for, when no target is given
+func withTarget(args cliargs.Args, rq http.Request) cliargs.Args {
+	if args.Host == "" {
+		args.Host = rq.Target
+	}
+	return args
+}
+
+// warnSchemeMismatch warns about a request captured over the other scheme than the target's,
+// e.g. with Host: example.com:443 sent to http://example.com. The target still wins.
+func warnSchemeMismatch(args cliargs.Args, rq http.Request) {
+	target, err := http.ParseTarget(args.Host)
+	if err != nil {
+		return
+	}
+	if implied := rq.ImpliedScheme(); implied != "" && implied != target.Scheme {
+		logging.Warnf("%v %v looks like a %v request, but the target is %v", rq.Method, rq.RequestUri, implied, target)
+	}
+}
+
 func readRawRequest(rqPath string) []byte {
 	rawRq, _ := os.ReadFile(rqPath)
 	return rawRq
@@ -72,6 +351,28 @@ func overwriteCookies(rqs []http.Request, args cliargs.Args) []http.Request {
 	return result
 }
 
+func withBodyFile(rqs []http.Request, args cliargs.Args) []http.Request {
+	result := []http.Request{}
+	for _, rq := range rqs {
+		rq, err := rq.WithBodyFile(args.BodyFile)
+		if err != nil {
+			atui.Fatal(err)
+		}
+		result = append(result, rq)
+	}
+	return result
+}
+
+func withMethods(rqs []http.Request, args cliargs.Args) []http.Request {
+	result := []http.Request{}
+	for _, rq := range rqs {
+		for _, m := range strings.Split(args.Methods, ",") {
+			result = append(result, rq.WithMethod(m))
+		}
+	}
+	return result
+}
+
 func overwriteHeaders(rqs []http.Request, args cliargs.Args) []http.Request {
 	result := []http.Request{}
 	for _, rq := range rqs {
@@ -83,35 +384,286 @@ func overwriteHeaders(rqs []http.Request, args cliargs.Args) []http.Request {
 	return result
 }
 
-func probe(rq http.Request, addr string) {
-	probe, err := rq.Send(addr)
+func send(rq http.Request, args cliargs.Args) (http.Response, error) {
+	if args.Raw {
+		return rq.SendRaw(args.Host)
+	}
+	return rq.Send(args.Host)
+}
+
+// sendRetrying resends the request while it responds with a -retry-on-status code, so that
+// transient gateway errors are not reported
+func sendRetrying(rq http.Request, args cliargs.Args) (http.Response, error) {
+	res, err := send(rq, args)
+	if args.RetryOnStatus == "" {
+		return res, err
+	}
+	retry := reportable.MatchCodes(args.RetryOnStatus)
+	for attempt := 1; attempt <= args.Retries && err == nil && retry(res); attempt++ {
+		logging.Debugf("%v %v responded with %v, retry %v of %v", rq.Method, rq.RequestUri, res.Code, attempt, args.Retries)
+		res, err = send(rq, args)
+	}
+	return res, err
+}
+
+func rawRequest(rq http.Request, args cliargs.Args) []byte {
+	if args.Raw {
+		return rq.WireBytes()
+	}
+	return rq.Raw(args.Host)
+}
+
+func probe(rq http.Request, args cliargs.Args) (http.Response, error) {
+	probe, err := sendRetrying(rq, args)
 	if err != nil {
-		atui.Fatal(err)
+		return probe, http.ExplainSchemeError(err, args.Host)
 	}
 	atui.Probe(probe)
+	return probe, nil
+}
+
+func replay(args cliargs.Args) {
+	rawRq, saved, err := report.ReadSaved(args.Replay)
+	if err != nil {
+		atui.Fatal(err)
+	}
+	res, err := send(http.Parse(rawRq), args)
+	if err != nil {
+		atui.Fatal(err)
+	}
+	atui.Replay(saved, res)
+}
+
+func mutables(args cliargs.Args) []mutable.Mutable {
+	mutables := mutable.AllMutatables()
+	if args.GraphqlQuery {
+		mutables = append(mutables, mutable.GraphqlQuery)
+	}
+	return mutables
+}
+
+func builtinMutations(args cliargs.Args) []mutation.Mutation {
+	if args.PayloadsOnly {
+		return []mutation.Mutation{}
+	}
+	if args.Crlf {
+		return append(mutation.AllMutations(), mutation.CrlfInjection)
+	}
+	return mutation.AllMutations()
+}
+
+func forEachMutant(args cliargs.Args, rq http.Request, each func(mutation.Mutant) bool) {
+	// the byte mutants are sent as they are, re-encoding their body makes no sense
+	for _, mut := range byteMutants(args, rq) {
+		if !each(mut) {
+			return
+		}
+	}
+	if charsets := charsetsOf(args, rq); len(charsets) > 0 {
+		each = withCharsets(charsets, each)
+	}
+	mtbls := mutables(args)
+	order := mutantOrder(args)
+	for _, mut := range order(mutation.Mutate(rq, builtinMutations(args), mtbls)) {
+		if !each(mut) {
+			return
+		}
+	}
+
+	for _, path := range args.Payloads {
+		stopped := false
+		err := mutation.ReadPayloads(path, func(payload mutation.Mutation) bool {
+			for _, mut := range order(mutation.Mutate(rq, []mutation.Mutation{payload}, mtbls)) {
+				if !each(mut) {
+					stopped = true
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			atui.Error(err)
+		}
+		if stopped {
+			return
+		}
+	}
 }
 
-func fuzz(args cliargs.Args, rq http.Request, reportDir string) {
+func byteMutants(args cliargs.Args, rq http.Request) []mutation.Mutant {
+	if args.ByteOps == "" {
+		return nil
+	}
+	ops, _ := mutation.ParseByteOperators(args.ByteOps)
+	return mutation.MutateBytes(rq, ops, args.ByteCount, args.Seed)
+}
+
+// charsetsOf lists the -charsets the body of the request can be re-encoded to
+func charsetsOf(args cliargs.Args, rq http.Request) []string {
+	if args.Charsets == "" || !rq.HasTextBody() {
+		return nil
+	}
+	return strings.Split(args.Charsets, ",")
+}
+
+// withCharsets follows each mutant of the body with its copies re-encoded to the charsets
+func withCharsets(charsets []string, each func(mutation.Mutant) bool) func(mutation.Mutant) bool {
+	return func(mut mutation.Mutant) bool {
+		if !each(mut) {
+			return false
+		}
+		if !mutable.IsBody(mut.Mutable) {
+			return true
+		}
+		for _, charset := range charsets {
+			encoded, err := mutation.InCharset(mut, charset)
+			if err != nil {
+				logging.Warnf("%v: %v", mut, err)
+				continue
+			}
+			if !each(encoded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// mutantOrder shuffles the mutants with -shuffle. Every request starts from the same seed,
+// so that a dry run and a fuzzing run with the same seed send the mutants in the same order.
+func mutantOrder(args cliargs.Args) func([]mutation.Mutant) []mutation.Mutant {
+	if !args.Shuffle {
+		return func(muts []mutation.Mutant) []mutation.Mutant { return muts }
+	}
+	rng := rand.New(rand.NewSource(args.Seed))
+	return func(muts []mutation.Mutant) []mutation.Mutant {
+		rng.Shuffle(len(muts), func(i, j int) { muts[i], muts[j] = muts[j], muts[i] })
+		return muts
+	}
+}
+
+func countMutants(args cliargs.Args, rq http.Request) int {
+	mtbls := mutables(args)
+	count := countStructured(args, rq, mtbls)
+	if charsets := charsetsOf(args, rq); len(charsets) > 0 {
+		body := []mutable.Mutable{}
+		for _, m := range mtbls {
+			if mutable.IsBody(m.Name) {
+				body = append(body, m)
+			}
+		}
+		count += len(charsets) * countStructured(args, rq, body)
+	}
+	if args.ByteOps != "" {
+		ops, _ := mutation.ParseByteOperators(args.ByteOps)
+		count += mutation.CountByteMutants(rq, ops, args.ByteCount)
+	}
+	return count
+}
+
+func countStructured(args cliargs.Args, rq http.Request, mtbls []mutable.Mutable) int {
+	count := mutation.Count(rq, builtinMutations(args), mtbls)
+	for _, path := range args.Payloads {
+		payloads, _ := mutation.CountMutants(rq, path, mtbls)
+		count += payloads
+	}
+	return count
+}
+
+// plannedRequests counts the mutated requests the run is going to send, up to -max-requests
+func plannedRequests(args cliargs.Args, iterations []map[string]string) int {
+	if args.ProbeOnly {
+		return 0
+	}
+	count := 0
+	for _, targetArgs := range perTarget(args) {
+		for _, rfile := range args.RequestFiles {
+			for _, rq := range parseRequestsFromFile(rfile, targetArgs, iterations) {
+				count += countMutants(withTarget(targetArgs, rq), rq)
+			}
+		}
+	}
+	if args.MaxRequests > 0 && count > args.MaxRequests {
+		return args.MaxRequests
+	}
+	return count
+}
+
+func dryRun(args cliargs.Args, rq http.Request) {
+	forEachMutant(args, rq, func(mut mutation.Mutant) bool {
+		atui.DryRun(mut, rawRequest(mut.Request, args))
+		return true
+	})
+}
+
+const calibrationTolerance = 5
+
+// newPool gives each target of a -targets-file its own workers, at most -host-threads of them,
+// which take turns on the -threads shared by all the targets
+func newPool(args cliargs.Args) workerpool.Pool {
+	if sharedThreads == nil {
+		return workerpool.NewPool(args.Threads)
+	}
+	size := args.Threads
+	if args.HostThreads > 0 && args.HostThreads < size {
+		size = args.HostThreads
+	}
+	return workerpool.NewSharedPool(size, sharedThreads)
+}
+
+func fuzz(args cliargs.Args, rq http.Request, baseline http.Response, reportDir string, stats *summary.Summary, quota *workerpool.Quota) {
 	matchers, filters := reportable.FromArgs(args)
-	muts := mutation.Mutate(rq, mutation.AllMutations(), mutable.AllMutatables())
-	bar := atui.ProgressBar(len(muts))
-	pool := workerpool.NewPool(args.Threads)
+	if args.AutoCalibrate {
+		filters = append(filters, reportable.FilterBaseline(baseline, calibrationTolerance))
+	}
+	origRaw := rawRequest(rq, args)
+	bar := atui.ProgressBar(quota.Remaining(countMutants(args, rq)))
+	pool := newPool(args)
 
-	for  _, mut := range muts {
-		mut := mut
+	forEachMutant(args, rq, func(mut mutation.Mutant) bool {
+		if !quota.Take() {
+			return false
+		}
 		task := func() {
-			res, err := mut.Send(args.Host)
+			logging.Debugf("sending %v: %v %v", mut, mut.Request.Method, mut.Request.RequestUri)
+			res, err := sendRetrying(mut.Request, args)
 			if err != nil {
-				atui.Error(err)
+				logging.Debugf("%v failed: %v", mut, err)
+				atui.RequestError(mut, err)
 			}
-			if reportable.IsReportable(res, matchers, filters) {
-				fname := report.Report(mut.Raw(args.Host), res.Raw, reportDir)
-				atui.Crash(res, fname)
+			isReportable := err == nil && reportable.IsReportable(res, reportable.WithReflection(matchers, args, mut.Payload), filters)
+			if isReportable && args.StopOnFirst {
+				isReportable = quota.Stop()
+				if isReportable {
+					logging.Infof("stopping after the first reported response")
+				}
 			}
+			if isReportable {
+				mutRaw := rawRequest(mut.Request, args)
+				diff := report.Diff(origRaw, mutRaw)
+				fname := report.Report(mut.String(), diff, mutRaw, res.TrimmedRaw(args.TrimBody), reportDir)
+				atui.Crash(res, mut, diff, fname)
+				hit := report.Hit{Mutation: mut.Mutation, Mutable: mut.Mutable, Tag: mut.Tag, Method: mut.Method,
+					Url: mut.Url(args.Host), Code: res.Code, Length: res.Length, Report: fname}
+				if markdown != nil {
+					markdown.Add(hit)
+				}
+				if sarif != nil {
+					sarif.Add(hit)
+				}
+				if httpFile != nil {
+					httpFile.Add(mut.Request.ToHttpFile(args.Host, mut.String()))
+				}
+				if notifier != nil {
+					notifier.Notify(hit)
+				}
+			}
+			stats.Add(res, err, isReportable, mut.Tag)
 			bar.Next()
 		}
 		pool.RunTask(task)
-	}
+		return true
+	})
 	pool.Wait()
 	bar.End()
 }
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"bytes"
+	"github.com/kamil-s-solecki/haze/cliargs"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/mutable"
+	"github.com/kamil-s-solecki/haze/mutation"
+	"github.com/kamil-s-solecki/haze/report"
+	"github.com/kamil-s-solecki/haze/summary"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"github.com/kamil-s-solecki/haze/tui"
+	"github.com/kamil-s-solecki/haze/workerpool"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDryRunPrintsEveryMutationWithoutSending(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		hits++
+	}))
+	defer srv.Close()
+	out := &bytes.Buffer{}
+	atui = tui.New(out)
+	args := cliargs.Args{Host: srv.URL, DryRun: true}
+	rq := http.Parse([]byte("GET /api/users?id=1 HTTP/1.1\r\nHost: localhost\r\nCookie: session=abc\r\n\r\n"))
+
+	dryRun(args, rq)
+
+	muts := []mutation.Mutant{}
+	forEachMutant(args, rq, func(mut mutation.Mutant) bool {
+		muts = append(muts, mut)
+		return true
+	})
+	testutils.AssertEquals(t, hits, 0)
+	testutils.AssertEquals(t, strings.Count(out.String(), "---- "), len(muts))
+	for _, mut := range muts {
+		testutils.AssertTrue(t, strings.Contains(out.String(), "---- "+mut.String()+" ----"))
+	}
+}
+
+func TestHeadersFromArgsAppearOnTheWire(t *testing.T) {
+	args := cliargs.Args{Host: "http://localhost", Headers: cliargs.StringArrayArg{"Authorization: Bearer abc", "X-Trace-Id: 123", "Accept: text/html"}}
+	rq := http.Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nAccept: */*\r\n\r\n"))
+
+	got := overwriteHeaders([]http.Request{rq}, args)
+
+	raw := string(got[0].Raw(args.Host))
+	testutils.AssertTrue(t, strings.Contains(raw, "Authorization: Bearer abc\r\n"))
+	testutils.AssertTrue(t, strings.Contains(raw, "X-Trace-Id: 123\r\n"))
+	testutils.AssertTrue(t, strings.Contains(raw, "Accept: text/html\r\n"))
+	testutils.AssertFalse(t, strings.Contains(raw, "*/*"))
+}
+
+func TestEachMethodIsFuzzed(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		mu.Lock()
+		seen[r.Method]++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	atui = tui.New(&bytes.Buffer{})
+	args := cliargs.Args{Host: srv.URL, Threads: 4, MatchCodes: "599", Methods: "GET,POST,DELETE"}
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	rqs := withMethods([]http.Request{rq}, args)
+	for _, r := range rqs {
+		fuzz(args, r, http.Response{}, t.TempDir(), summary.Start(), workerpool.NewQuota(0))
+	}
+
+	testutils.AssertLen(t, rqs, 3)
+	for _, m := range []string{"GET", "POST", "DELETE"} {
+		testutils.AssertTrue(t, seen[m] > 0)
+	}
+	testutils.AssertEquals(t, seen["GET"], seen["DELETE"])
+}
+
+func TestTemplateIterationsBecomeRequests(t *testing.T) {
+	dir := t.TempDir()
+	rfile := filepath.Join(dir, "rq.txt")
+	os.WriteFile(rfile, []byte("GET /users/{{ID}}?t={{TOKEN}} HTTP/1.1\r\nHost: localhost\r\n\r\n"), 0644)
+	dataset := filepath.Join(dir, "ids.csv")
+	os.WriteFile(dataset, []byte("ID\n1\n2\n"), 0644)
+	args := cliargs.Args{Host: "http://localhost", Vars: cliargs.StringArrayArg{"TOKEN=abc"}, VarsFile: dataset}
+
+	rqs := parseRequestsFromFile(rfile, args, templateIterations(args))
+
+	testutils.AssertLen(t, rqs, 2)
+	testutils.AssertEquals(t, rqs[0].RequestUri, "/users/1?t=abc")
+	testutils.AssertEquals(t, rqs[1].RequestUri, "/users/2?t=abc")
+}
+
+func TestContentLengthIsFixedAfterRendering(t *testing.T) {
+	dir := t.TempDir()
+	rfile := filepath.Join(dir, "rq.txt")
+	os.WriteFile(rfile, []byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 11\r\n\r\nt={{TOKEN}}"), 0644)
+	wrong := filepath.Join(dir, "wrong.txt")
+	os.WriteFile(wrong, []byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 100\r\n\r\nt={{TOKEN}}"), 0644)
+	args := cliargs.Args{Host: "http://localhost", Vars: cliargs.StringArrayArg{"TOKEN=abcdef"}}
+
+	rq := parseRequestsFromFile(rfile, args, templateIterations(args))[0]
+	wrongRq := parseRequestsFromFile(wrong, args, templateIterations(args))[0]
+
+	cl, _ := rq.Header("Content-Length")
+	testutils.AssertEquals(t, cl, "8")
+	cl, _ = wrongRq.Header("Content-Length")
+	testutils.AssertEquals(t, cl, "100")
+}
+
+func TestAutoCalibrateReportsOnlyDivergentResponses(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(500)
+		if strings.Contains(r.URL.String(), "'") {
+			w.Write([]byte("You have an error in your SQL syntax near ''' at line 1"))
+			return
+		}
+		w.Write([]byte("Internal error, request id " + r.URL.RawQuery[len(r.URL.RawQuery)-1:]))
+	}))
+	defer srv.Close()
+	atui = tui.New(&bytes.Buffer{})
+	args := cliargs.Args{Host: srv.URL, Threads: 4, MatchCodes: "500-599", AutoCalibrate: true}
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	baseline, _ := rq.Send(srv.URL)
+	stats := summary.Start()
+
+	fuzz(args, rq, baseline, t.TempDir(), stats, workerpool.NewQuota(0))
+
+	divergent := 0
+	forEachMutant(args, rq, func(mut mutation.Mutant) bool {
+		if strings.Contains(mut.Url(srv.URL), "'") {
+			divergent++
+		}
+		return true
+	})
+	testutils.AssertTrue(t, divergent > 0)
+	testutils.AssertTrue(t, divergent < stats.Requests)
+	testutils.AssertEquals(t, stats.Reported, divergent)
+}
+
+func TestStopOnFirstReportsExactlyOneHit(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(500)
+	}))
+	defer srv.Close()
+	out := &bytes.Buffer{}
+	atui = tui.New(out)
+	args := cliargs.Args{Host: srv.URL, Threads: 4, MatchCodes: "500-599", StopOnFirst: true}
+	rq := http.Parse([]byte("GET /a/b?id=1&name=foo HTTP/1.1\r\nHost: localhost\r\nCookie: sid=1\r\n\r\n"))
+	stats := summary.Start()
+	quota := workerpool.NewQuota(0)
+
+	fuzz(args, rq, http.Response{}, t.TempDir(), stats, quota)
+
+	testutils.AssertEquals(t, stats.Reported, 1)
+	testutils.AssertEquals(t, strings.Count(out.String(), "Crash:"), 1)
+	testutils.AssertTrue(t, stats.Requests < countMutants(args, rq))
+	testutils.AssertTrue(t, quota.Exhausted())
+}
+
+func TestInterruptFinishesWithPartialSummary(t *testing.T) {
+	var once sync.Once
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		once.Do(func() {
+			self, _ := os.FindProcess(os.Getpid())
+			self.Signal(os.Interrupt)
+		})
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer srv.Close()
+	out := &bytes.Buffer{}
+	atui = tui.New(out)
+	args := cliargs.Args{Host: srv.URL, Threads: 2, MatchCodes: "500-599"}
+	rq := http.Parse([]byte("GET /a/b?id=1&name=foo HTTP/1.1\r\nHost: localhost\r\nCookie: sid=1\r\n\r\n"))
+	stats := summary.Start()
+	quota := workerpool.NewQuota(0)
+
+	stop := interruptOnSignal(stats, quota)
+	fuzz(args, rq, http.Response{}, t.TempDir(), stats, quota)
+	stop()
+	atui.PrintSummary(stats)
+
+	testutils.AssertTrue(t, stats.Interrupted)
+	testutils.AssertTrue(t, stats.Requests > 0)
+	testutils.AssertTrue(t, stats.Requests < countMutants(args, rq))
+	testutils.AssertTrue(t, strings.Contains(out.String(), "Interrupted"))
+}
+
+func TestSavedResponseIsTrimmedButMatchedWhole(t *testing.T) {
+	body := strings.Repeat("a", 5000) + "needle"
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	atui = tui.New(&bytes.Buffer{})
+	dir := t.TempDir()
+	args := cliargs.Args{Host: srv.URL, Threads: 1, MatchCodes: "500-599", MatchString: "needle", TrimBody: 100, MaxRequests: 1}
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	stats := summary.Start()
+
+	fuzz(args, rq, http.Response{}, dir, stats, workerpool.NewQuota(args.MaxRequests))
+	entries, _ := os.ReadDir(dir)
+	_, saved, err := report.ReadSaved(filepath.Join(dir, entries[0].Name()))
+
+	testutils.AssertEquals(t, stats.Reported, 1)
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertFalse(t, strings.Contains(string(saved), "needle"))
+	testutils.AssertTrue(t, strings.HasSuffix(string(saved), strings.Repeat("a", 100)+"\r\n[... 4906 more bytes trimmed, see -trim-body]"))
+}
+
+func TestSameSeedGivesSameMutantOrder(t *testing.T) {
+	rq := http.Parse([]byte("POST /a/b?id=1&name=foo HTTP/1.1\r\nHost: localhost\r\nX-Foo: foo\r\nX-Bar: bar\r\n" +
+		"Content-Type: application/json\r\nCookie: sid=1; lang=en\r\n\r\n{\"a\": 1, \"b\": {\"c\": \"d\", \"e\": [1, 2]}}"))
+	order := func(args cliargs.Args) []string {
+		muts := []string{}
+		forEachMutant(args, rq, func(mut mutation.Mutant) bool {
+			muts = append(muts, mut.String()+" "+string(mut.WireBytes()))
+			return true
+		})
+		return muts
+	}
+
+	natural := strings.Join(order(cliargs.Args{}), "\n")
+	seeded := strings.Join(order(cliargs.Args{Shuffle: true, Seed: 42}), "\n")
+
+	for i := 0; i < 5; i++ {
+		testutils.AssertEquals(t, strings.Join(order(cliargs.Args{}), "\n"), natural)
+		testutils.AssertEquals(t, strings.Join(order(cliargs.Args{Shuffle: true, Seed: 42}), "\n"), seeded)
+	}
+	testutils.AssertTrue(t, seeded != natural)
+	testutils.AssertTrue(t, strings.Join(order(cliargs.Args{Shuffle: true, Seed: 43}), "\n") != seeded)
+}
+
+func TestReplaySendsSavedRequestAndPrintsBothResponses(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		hits++
+		w.WriteHeader(500)
+		w.Write([]byte("fresh " + r.URL.Query().Get("id")))
+	}))
+	defer srv.Close()
+	out := &bytes.Buffer{}
+	atui = tui.New(out)
+	dir := t.TempDir()
+	rq := http.Parse([]byte("GET /?id=1' HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	fname := report.Report("SingleQuotes Parameter id", "", rq.Raw(srv.URL), []byte("HTTP/1.1 500 Internal Server Error\r\n\r\nsaved"), dir)
+	args := cliargs.Args{Host: srv.URL, Replay: filepath.Join(dir, fname)}
+
+	replay(args)
+
+	testutils.AssertEquals(t, hits, 1)
+	testutils.AssertTrue(t, strings.Contains(out.String(), "---- Saved response ----\nHTTP/1.1 500 Internal Server Error\r\n\r\nsaved"))
+	testutils.AssertTrue(t, strings.Contains(out.String(), "---- Fresh response ----\nHTTP/1.1 500 Internal Server Error"))
+	testutils.AssertTrue(t, strings.Contains(out.String(), "fresh 1'"))
+}
+
+func TestStripHopKeepsHopByHopHeadersOnTheRawPath(t *testing.T) {
+	rfile := filepath.Join(t.TempDir(), "rq.txt")
+	os.WriteFile(rfile, []byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\nProxy-Connection: keep-alive\r\n\r\n"), 0644)
+	args := cliargs.Args{Host: "http://localhost", StripHop: true}
+	iterations := templateIterations(args)
+
+	stripped := parseRequestsFromFile(rfile, args, iterations)[0]
+	args.Raw = true
+	raw := parseRequestsFromFile(rfile, args, iterations)[0]
+
+	testutils.AssertFalse(t, strings.Contains(string(stripped.Raw(args.Host)), "Proxy-Connection"))
+	testutils.AssertTrue(t, strings.Contains(string(raw.WireBytes()), "\r\nConnection: keep-alive\r\n"))
+	testutils.AssertTrue(t, strings.Contains(string(raw.WireBytes()), "\r\nProxy-Connection: keep-alive\r\n"))
+}
+
+func TestHarEntriesAreSentToTheirOwnHostsWithoutTarget(t *testing.T) {
+	hits := map[string]int{}
+	var mu sync.Mutex
+	handler := func(name string) nethttp.HandlerFunc {
+		return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			mu.Lock()
+			hits[name+" "+r.URL.Path]++
+			mu.Unlock()
+		}
+	}
+	a := httptest.NewServer(handler("a"))
+	defer a.Close()
+	b := httptest.NewServer(handler("b"))
+	defer b.Close()
+	atui = tui.New(&bytes.Buffer{})
+	rfile := filepath.Join(t.TempDir(), "session.har")
+	entry := `{"request": {"method": "GET", "url": "%v", "headers": [], "cookies": []}}`
+	os.WriteFile(rfile, []byte(`{"log": {"entries": [`+strings.Join([]string{
+		strings.Replace(entry, "%v", a.URL+"/first", 1),
+		strings.Replace(entry, "%v", "data:text/plain,hello", 1),
+		strings.Replace(entry, "%v", b.URL+"/second", 1),
+	}, ",")+`]}}`), 0644)
+	args := cliargs.Args{Har: true}
+
+	rqs := parseRequestsFromFile(rfile, args, templateIterations(args))
+	for _, rq := range rqs {
+		probe(rq, withTarget(args, rq))
+	}
+
+	testutils.AssertLen(t, rqs, 2)
+	testutils.AssertEquals(t, hits["a /first"], 1)
+	testutils.AssertEquals(t, hits["b /second"], 1)
+	testutils.AssertEquals(t, len(hits), 2)
+}
+
+func TestPlannedRequestsMatchTheRequestsSent(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+	defer srv.Close()
+	atui = tui.New(&bytes.Buffer{})
+	dir := t.TempDir()
+	rfiles := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	os.WriteFile(rfiles[0], []byte("GET /a?id=1 HTTP/1.1\r\nHost: localhost\r\nCookie: sid=1\r\n\r\n"), 0644)
+	os.WriteFile(rfiles[1], []byte("POST /b HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\nx=1&y=2"), 0644)
+	wordlist := filepath.Join(dir, "payloads.txt")
+	os.WriteFile(wordlist, []byte("#tag: sqli\n'\n#tag:\n# comment\nx\rX-Injected: 1\n<b>\n"), 0644)
+
+	for _, max := range []int{0, 50} {
+		args := cliargs.Args{Host: srv.URL, Threads: 4, MatchCodes: "599", Methods: "GET,PUT",
+			Payloads: cliargs.StringArrayArg{wordlist}, RequestFiles: rfiles, MaxRequests: max}
+		iterations := templateIterations(args)
+		stats := summary.Start()
+		quota := workerpool.NewQuota(args.MaxRequests)
+
+		for _, rfile := range args.RequestFiles {
+			for _, rq := range parseRequestsFromFile(rfile, args, iterations) {
+				fuzz(args, rq, http.Response{}, dir, stats, quota)
+			}
+		}
+
+		testutils.AssertTrue(t, stats.Requests > 0)
+		testutils.AssertEquals(t, plannedRequests(args, iterations), stats.Requests)
+	}
+}
+
+func TestByteMutantsAreSentAlongTheStructuredOnes(t *testing.T) {
+	args := cliargs.Args{Host: "http://localhost", ByteOps: "insert,delete", ByteCount: 5, Seed: 1}
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	wires := 0
+	forEachMutant(args, rq, func(mut mutation.Mutant) bool {
+		if mut.Wire != nil {
+			wires++
+		}
+		return true
+	})
+
+	testutils.AssertEquals(t, wires, 10)
+	testutils.AssertEquals(t, countMutants(args, rq), 10+countMutants(cliargs.Args{Host: args.Host}, rq))
+}
+
+func TestEachBodyMutantIsAlsoSentInEveryCharset(t *testing.T) {
+	args := cliargs.Args{Host: "http://localhost", Charsets: "utf-16,utf-8-overlong"}
+	rq := http.Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\na=1"))
+
+	muts := []mutation.Mutant{}
+	forEachMutant(args, rq, func(mut mutation.Mutant) bool {
+		muts = append(muts, mut)
+		return true
+	})
+
+	body, first := 0, -1
+	for i, mut := range muts {
+		if mut.Charset != "" {
+			testutils.AssertTrue(t, mutable.IsBody(mut.Mutable))
+		} else if mutable.IsBody(mut.Mutable) {
+			body++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	testutils.AssertTrue(t, body > 0)
+	testutils.AssertEquals(t, len(muts), countMutants(args, rq))
+	testutils.AssertEquals(t, len(muts), countMutants(cliargs.Args{Host: args.Host}, rq)+2*body)
+	testutils.AssertEquals(t, muts[first+1].String(), muts[first].String()+" in utf-16")
+	testutils.AssertEquals(t, muts[first+1].Headers["Content-Type"], "application/x-www-form-urlencoded; charset=utf-16")
+	testutils.AssertEquals(t, muts[first+2].Charset, "utf-8-overlong")
+}
+
+func TestCharsetsAreSkippedForBodilessRequests(t *testing.T) {
+	args := cliargs.Args{Host: "http://localhost", Charsets: "utf-16"}
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	testutils.AssertEquals(t, countMutants(args, rq), countMutants(cliargs.Args{Host: args.Host}, rq))
+}
+
+func flakyServer(failures int) *httptest.Server {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	return httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		mu.Lock()
+		hits[r.URL.String()+r.Header.Get("Cookie")]++
+		n := hits[r.URL.String()+r.Header.Get("Cookie")]
+		mu.Unlock()
+		if n <= failures {
+			w.WriteHeader(503)
+		}
+	}))
+}
+
+func TestRetryOnStatusResendsUntilTheCodeChanges(t *testing.T) {
+	rq := http.Parse([]byte("GET /a HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	for _, c := range []struct{ retries, code int }{{2, 200}, {1, 503}, {0, 503}} {
+		srv := flakyServer(2)
+		args := cliargs.Args{Host: srv.URL, RetryOnStatus: "502,503", Retries: c.retries}
+
+		res, err := sendRetrying(rq, args)
+
+		testutils.AssertTrue(t, err == nil)
+		testutils.AssertEquals(t, res.Code, c.code)
+		srv.Close()
+	}
+}
+
+func TestTransientGatewayErrorsAreNotReported(t *testing.T) {
+	srv := flakyServer(2)
+	defer srv.Close()
+	out := &bytes.Buffer{}
+	atui = tui.New(out)
+	rq := http.Parse([]byte("GET /a?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	stats := summary.Start()
+
+	args := cliargs.Args{Host: srv.URL, Threads: 4, MatchCodes: "500-599", RetryOnStatus: "503", Retries: 2}
+	fuzz(args, rq, http.Response{}, t.TempDir(), stats, workerpool.NewQuota(0))
+
+	testutils.AssertTrue(t, stats.Requests > 0)
+	testutils.AssertEquals(t, stats.Reported, 0)
+	testutils.AssertEquals(t, stats.Classes[2], stats.Requests)
+}
+
+func TestEveryHostOfTheTargetsFileIsFuzzed(t *testing.T) {
+	hits := map[string]int{}
+	var mu sync.Mutex
+	handler := func(name string) nethttp.HandlerFunc {
+		return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			mu.Lock()
+			hits[name]++
+			mu.Unlock()
+			w.WriteHeader(500)
+		}
+	}
+	a := httptest.NewServer(handler("a"))
+	defer a.Close()
+	b := httptest.NewServer(handler("b"))
+	defer b.Close()
+	out := &bytes.Buffer{}
+	atui = tui.New(out)
+	dir := t.TempDir()
+	targets := filepath.Join(dir, "targets.txt")
+	os.WriteFile(targets, []byte("# scope\n"+a.URL+"\n\n"+b.URL+"\n"), 0644)
+	rfile := filepath.Join(dir, "rq.txt")
+	os.WriteFile(rfile, []byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"), 0644)
+	args := cliargs.Args{TargetsFile: targets, RequestFiles: []string{rfile}, Threads: 4, MatchCodes: "500-599"}
+	atui.Configure(args)
+	stats := summary.Start()
+
+	fuzzAll(args, templateIterations(args), dir, stats, workerpool.NewQuota(0))
+
+	perHost := countMutants(cliargs.Args{Host: a.URL}, http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n")))
+	testutils.AssertEquals(t, hits["a"], perHost+1)
+	testutils.AssertEquals(t, hits["b"], perHost+1)
+	testutils.AssertEquals(t, stats.Reported, 2*perHost)
+	testutils.AssertEquals(t, plannedRequests(args, templateIterations(args)), 2*perHost)
+	testutils.AssertTrue(t, strings.Contains(out.String(), "Crash:      [Code: 500, Len: 0] [sqli] "+b.URL+" SingleQuotes @ Path"))
+}
+
+func TestDeadTargetIsSkipped(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) { atomic.AddInt64(&hits, 1) }))
+	defer srv.Close()
+	dead := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+	dead.Close()
+	out := &bytes.Buffer{}
+	atui = tui.New(out)
+	dir := t.TempDir()
+	targets := filepath.Join(dir, "targets.txt")
+	os.WriteFile(targets, []byte(dead.URL+"\n"+srv.URL+"\n"), 0644)
+	rfile := filepath.Join(dir, "rq.txt")
+	os.WriteFile(rfile, []byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"), 0644)
+	args := cliargs.Args{TargetsFile: targets, RequestFiles: []string{rfile}, Threads: 1, MatchCodes: "500-599"}
+	atui.Configure(args)
+
+	fuzzAll(args, templateIterations(args), dir, summary.Start(), workerpool.NewQuota(0))
+
+	testutils.AssertTrue(t, strings.Contains(out.String(), "skipping "+dead.URL))
+	testutils.AssertTrue(t, atomic.LoadInt64(&hits) > 1)
+}
+
+// enter counts a request in flight, keeps the peak and returns the func counting it out
+func enter(inFlight, peak *int64) func() {
+	n := atomic.AddInt64(inFlight, 1)
+	for max := atomic.LoadInt64(peak); n > max; max = atomic.LoadInt64(peak) {
+		atomic.CompareAndSwapInt64(peak, max, n)
+	}
+	return func() { atomic.AddInt64(inFlight, -1) }
+}
+
+func TestTargetsAreFuzzedSideBySideUnderTheHostCap(t *testing.T) {
+	var inFlight, peak int64
+	peaks := map[string]*int64{"a": new(int64), "b": new(int64)}
+	handler := func(name string) nethttp.HandlerFunc {
+		var hostInFlight int64
+		return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			defer enter(&inFlight, &peak)()
+			defer enter(&hostInFlight, peaks[name])()
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+	a := httptest.NewServer(handler("a"))
+	defer a.Close()
+	b := httptest.NewServer(handler("b"))
+	defer b.Close()
+	defer func(max int) { http.MaxPerHost = max }(http.MaxPerHost)
+	http.MaxPerHost = 1
+	atui = tui.New(&bytes.Buffer{})
+	dir := t.TempDir()
+	targets := filepath.Join(dir, "targets.txt")
+	os.WriteFile(targets, []byte(a.URL+"\n"+b.URL+"\n"), 0644)
+	rfile := filepath.Join(dir, "rq.txt")
+	os.WriteFile(rfile, []byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"), 0644)
+	args := cliargs.Args{TargetsFile: targets, RequestFiles: []string{rfile}, Threads: 4, HostThreads: 1, MatchCodes: "500-599"}
+	atui.Configure(args)
+
+	fuzzAll(args, templateIterations(args), dir, summary.Start(), workerpool.NewQuota(0))
+
+	testutils.AssertEquals(t, atomic.LoadInt64(peaks["a"]), int64(1))
+	testutils.AssertEquals(t, atomic.LoadInt64(peaks["b"]), int64(1))
+	testutils.AssertEquals(t, atomic.LoadInt64(&peak), int64(2))
+}
@@ -3,14 +3,22 @@ package mutable
 import (
 	"github.com/kamil-s-solecki/haze/http"
 	"github.com/kamil-s-solecki/haze/utils"
+	"regexp"
 )
 
 var Cookie = Mutable{"Cookie", cookie}
 
+// betweenCrlf leaves the CR and LF of the payload raw in CRLF mode, encoding the rest of the value as usual
+var betweenCrlf = regexp.MustCompile("[^\r\n]+")
+
 func cookie(rq http.Request, trans func(string) string) []http.Request {
 	result := []http.Request{}
-	for key, val := range rq.Cookies {
-		enc := utils.UrlEncodeSpecials(trans(val))
+	for _, key := range utils.SortedKeys(rq.Cookies) {
+		mutated := trans(rq.Cookies[key])
+		enc := utils.UrlEncodeSpecials(mutated)
+		if AllowCrlf {
+			enc = betweenCrlf.ReplaceAllStringFunc(mutated, utils.UrlEncodeSpecials)
+		}
 		result = append(result, rq.WithCookie(key, enc))
 	}
 	return result
@@ -22,14 +22,29 @@ func urlDecode(val string) string {
 func cookieJsonParameterWithPostProcessing(rq http.Request, trans func(string) string, post func([]byte) []byte) []http.Request {
 	result := []http.Request{}
 
-	for key, val := range rq.Cookies {
+	for _, key := range utils.SortedKeys(rq.Cookies) {
+		val := rq.Cookies[key]
 		if !rq.HasJsonCookie(key) {
 			continue
 		}
 		data, _ := decodeJson([]byte(urlDecode(val)))
+		if !isJsonContainer(data) {
+			continue
+		}
 		for _, mutJson := range mutateJson(data, trans) {
 			result = append(result, rq.WithCookie(key, utils.UrlEncodeSpecials(string(post(mutJson)))))
 		}
 	}
 	return result
 }
+
+// isJsonContainer tells a JSON object or array from a plain value like 1 or true, which the Cookie
+// mutable already covers
+func isJsonContainer(data interface{}) bool {
+	switch data.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
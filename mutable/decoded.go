@@ -0,0 +1,58 @@
+package mutable
+
+import (
+	"bytes"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/utils"
+	"net/url"
+)
+
+type Encoder struct {
+	Name   string
+	Decode func(val string) (decoded string, encode func(string) string, ok bool)
+}
+
+var Base64 = Encoder{"Base64", decodeBase64}
+
+func decodeBase64(val string) (string, func(string) string, bool) {
+	enc, decoded, ok := utils.DetectBase64(val)
+	if !ok {
+		return "", nil, false
+	}
+	return string(decoded), func(s string) string { return enc.EncodeToString([]byte(s)) }, true
+}
+
+var Base64Parameter = WithDecodedPayload(Base64, Parameter)
+var Base64BodyParameter = WithDecodedPayload(Base64, BodyParameter)
+var Base64Cookie = WithDecodedPayload(Base64, Cookie)
+
+func WithDecodedPayload(enc Encoder, inner Mutable) Mutable {
+	apply := func(rq http.Request, trans func(string) string) []http.Request {
+		decodedTrans := func(val string) string {
+			unescaped, err := url.PathUnescape(val)
+			escaped := err == nil && unescaped != val
+			if !escaped {
+				unescaped = val
+			}
+			decoded, encode, ok := enc.Decode(unescaped)
+			if !ok {
+				return val
+			}
+			mutated := encode(trans(decoded))
+			if escaped {
+				mutated = url.QueryEscape(mutated)
+			}
+			return mutated
+		}
+
+		result := []http.Request{}
+		orig := rq.WireBytes()
+		for _, mut := range inner.Apply(rq, decodedTrans) {
+			if !bytes.Equal(mut.WireBytes(), orig) {
+				result = append(result, mut)
+			}
+		}
+		return result
+	}
+	return Mutable{enc.Name + inner.Name, apply}
+}
@@ -0,0 +1,37 @@
+package mutable
+
+import (
+	"fmt"
+	"github.com/kamil-s-solecki/haze/http"
+	"strings"
+)
+
+var GraphqlVariable = Mutable{"GraphqlVariable", graphqlVariable}
+
+func graphqlVariable(rq http.Request, trans func(string) string) []http.Request {
+	result := []http.Request{}
+	if !rq.HasGraphqlBody() {
+		return result
+	}
+	for _, f := range rq.JsonFields() {
+		if !strings.HasPrefix(f.Path, "variables.") && !strings.HasPrefix(f.Path, "variables[") {
+			continue
+		}
+		result = append(result, rq.WithJsonField(f.Path, trans(fmt.Sprintf("%v", f.Value))))
+	}
+	return result
+}
+
+var GraphqlQuery = Mutable{"GraphqlQuery", graphqlQuery}
+
+func graphqlQuery(rq http.Request, trans func(string) string) []http.Request {
+	if !rq.HasGraphqlBody() {
+		return []http.Request{}
+	}
+	for _, f := range rq.JsonFields() {
+		if f.Path == "query" {
+			return []http.Request{rq.WithJsonField("query", trans(f.Value.(string)))}
+		}
+	}
+	return []http.Request{}
+}
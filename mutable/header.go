@@ -2,14 +2,17 @@ package mutable
 
 import (
 	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/utils"
+	"net/textproto"
 )
 
 var Header = Mutable{"Header", header}
 
 func header(rq http.Request, trans func(string) string) []http.Request {
 	result := []http.Request{}
-	for key, val := range rq.Headers {
-		switch key {
+	for _, key := range utils.SortedKeys(rq.Headers) {
+		val := rq.Headers[key]
+		switch textproto.CanonicalMIMEHeaderKey(key) {
 		case "Content-Type", "Accept-Encoding", "Content-Encoding",
 			"Connection", "Content-Length", "Host":
 			continue
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/utils"
 	"strings"
 )
 
@@ -33,7 +34,7 @@ func jsonParameterRaw(rq http.Request, trans func(string) string) []http.Request
 }
 
 func jsonParameterWithPostProcessing(rq http.Request, trans func(string) string, post func([]byte) []byte) []http.Request {
-	if !rq.HasJsonBody() {
+	if !rq.HasJsonBody() || rq.HasGraphqlBody() {
 		return []http.Request{}
 	}
 
@@ -87,7 +88,8 @@ func mutateFlatJson(data *interface{}, trans func(string) string) []JsonMutation
 
 func mutateJsonRecursive(data map[string]interface{}, trans func(string) string) []JsonMutation {
 	agg := []JsonMutation{}
-	for key, val := range data {
+	for _, key := range utils.SortedKeys(data) {
+		val := data[key]
 		switch val.(type) {
 		case map[string]interface{}:
 			subs := mutateJsonRecursive(val.(map[string]interface{}), trans)
@@ -111,6 +113,8 @@ func mutateJsonArray(arr []interface{}, trans func(string) string) []JsonMutatio
 		case map[string]interface{}:
 			muts := mutateJsonRecursive(v.(map[string]interface{}), trans)
 			res = append(res, muts...)
+		case []any:
+			res = append(res, mutateJsonArray(v.([]interface{}), trans)...)
 		default:
 			mut := JsonMutation{
 				Apply: func() {
@@ -57,7 +57,8 @@ func findValueRange(body, boundary []byte, from int) (int, int) {
 
 func extractBoundary(rq http.Request) []byte {
 	r, _ := regexp.Compile("boundary=([^;]*)(;|$)")
-	return []byte("\r\n--" + r.FindStringSubmatch(rq.Headers["Content-Type"])[1])
+	ct, _ := rq.Header("Content-Type")
+	return []byte("\r\n--" + r.FindStringSubmatch(ct)[1])
 }
 
 func copySlice(b []byte, start, end int) []byte {
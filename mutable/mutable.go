@@ -9,6 +9,19 @@ type Mutable struct {
 	Apply func(http.Request, func(string) string) []http.Request
 }
 
+// AllowCrlf keeps CR and LF in header and cookie values, for CRLF injection testing.
+// Such requests can only be sent over a raw connection.
+var AllowCrlf = false
+
 func AllMutatables() []Mutable {
-	return []Mutable{Path, Parameter, ParameterName, BodyParameter, BodyParameterName, MultipartFormParameter, Header, Cookie, JsonParameter, JsonParameterRaw, CookieJsonParameter}
+	return []Mutable{Path, PathSegment, Parameter, ParameterName, BodyParameter, BodyParameterName, MultipartFormParameter, Header, Cookie, JsonParameter, JsonParameterRaw, CookieJsonParameter, XmlValue, GraphqlVariable, Base64Parameter, Base64BodyParameter, Base64Cookie}
+}
+
+var bodyMutables = map[string]bool{BodyParameter.Name: true, BodyParameterName.Name: true, MultipartFormParameter.Name: true,
+	JsonParameter.Name: true, JsonParameterRaw.Name: true, XmlValue.Name: true, GraphqlVariable.Name: true, GraphqlQuery.Name: true,
+	Base64BodyParameter.Name: true}
+
+// IsBody tells the mutables which change the body of the request from the others
+func IsBody(name string) bool {
+	return bodyMutables[name]
 }
@@ -3,12 +3,28 @@ package mutable
 import (
 	"github.com/kamil-s-solecki/haze/http"
 	"github.com/kamil-s-solecki/haze/utils"
+	"strings"
 )
 
 var Path = Mutable{"Path", path}
 
 func path(rq http.Request, trans func(string) string) []http.Request {
-	noLeadingSlash := rq.Path[1:]
+	noLeadingSlash := strings.TrimPrefix(rq.Path, "/")
 	val := utils.UrlEncodeSpecials(trans(noLeadingSlash))
 	return []http.Request{rq.WithPath("/" + val)}
 }
+
+var PathSegment = Mutable{"PathSegment", pathSegment}
+
+func pathSegment(rq http.Request, trans func(string) string) []http.Request {
+	result := []http.Request{}
+	segments := rq.PathSegments()
+	if len(segments) < 2 {
+		return result
+	}
+	for i, seg := range segments {
+		val := utils.UrlEncodeSpecials(trans(seg))
+		result = append(result, rq.WithPathSegment(i, val))
+	}
+	return result
+}
@@ -3,7 +3,6 @@ package mutable
 import (
 	"github.com/kamil-s-solecki/haze/http"
 	"github.com/kamil-s-solecki/haze/utils"
-	"strings"
 )
 
 var Parameter = Mutable{"Parameter", parameter}
@@ -70,14 +69,14 @@ func bodyParameterName(rq http.Request, trans func(string) string) []http.Reques
 	return result
 }
 
-func applyToEachParam(params string, do func(key, val string) (string, string)) []string {
+func applyToEachParam(raw string, do func(key, val string) (string, string)) []string {
 	result := []string{}
-	for _, p := range strings.Split(params, "&") {
-		key := strings.Split(p, "=")[0]
-		val := strings.Split(p, "=")[1]
-		mutKey, mutVal := do(key, val)
-		q := strings.Replace(params, p, mutKey+"="+mutVal, 1)
-		result = append(result, q)
+	params := http.ParseParams(raw)
+	for i, p := range params {
+		mutated := append([]http.Param{}, params...)
+		mutKey, mutVal := do(p.Key, p.Value)
+		mutated[i] = http.Param{Key: mutKey, Value: mutVal, HasValue: p.HasValue || mutVal != p.Value}
+		result = append(result, http.EncodeParams(mutated))
 	}
 	return result
 }
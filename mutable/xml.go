@@ -0,0 +1,18 @@
+package mutable
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+)
+
+var XmlValue = Mutable{"XmlValue", xmlValue}
+
+func xmlValue(rq http.Request, trans func(string) string) []http.Request {
+	result := []http.Request{}
+	if !rq.HasXmlBody() {
+		return result
+	}
+	for _, v := range rq.XmlValues() {
+		result = append(result, rq.WithXmlValue(v.Path, trans(v.Value)))
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package mutation
+
+import (
+	"fmt"
+	"github.com/kamil-s-solecki/haze/http"
+	"math/rand"
+	"strings"
+)
+
+// ByteOperator mutates the whole serialized request rather than a value in it, which finds bugs
+// in the parsers of the target. It returns the mutated bytes and where they were changed.
+type ByteOperator struct {
+	name  string
+	apply func(rng *rand.Rand, wire []byte) ([]byte, string)
+}
+
+const maxByteSpan = 16
+
+var Bitflip = ByteOperator{"Bitflip", bitflip}
+
+func bitflip(rng *rand.Rand, wire []byte) ([]byte, string) {
+	result := append([]byte{}, wire...)
+	at, bit := rng.Intn(len(wire)), rng.Intn(8)
+	result[at] ^= 1 << bit
+	return result, fmt.Sprintf("Byte %v bit %v", at, bit)
+}
+
+var InsertBytes = ByteOperator{"InsertBytes", insertBytes}
+
+func insertBytes(rng *rand.Rand, wire []byte) ([]byte, string) {
+	at := rng.Intn(len(wire) + 1)
+	inserted := make([]byte, 1+rng.Intn(maxByteSpan))
+	rng.Read(inserted)
+	return splice(wire, at, at, inserted), fmt.Sprintf("Byte %v", at)
+}
+
+var DeleteBytes = ByteOperator{"DeleteBytes", deleteBytes}
+
+// deleteBytes always leaves a byte, so that something is still sent
+func deleteBytes(rng *rand.Rand, wire []byte) ([]byte, string) {
+	from, to := span(rng, len(wire), len(wire)-1)
+	return splice(wire, from, to, nil), byteRange(from, to)
+}
+
+var DuplicateBytes = ByteOperator{"DuplicateBytes", duplicateBytes}
+
+func duplicateBytes(rng *rand.Rand, wire []byte) ([]byte, string) {
+	from, to := span(rng, len(wire), len(wire))
+	return splice(wire, to, to, wire[from:to]), byteRange(from, to)
+}
+
+// span picks from..to of at least one and at most maxByteSpan bytes, nor more than max
+func span(rng *rand.Rand, length, max int) (int, int) {
+	if max > maxByteSpan {
+		max = maxByteSpan
+	}
+	size := 1 + rng.Intn(max)
+	from := rng.Intn(length - size + 1)
+	return from, from + size
+}
+
+func splice(wire []byte, from, to int, inserted []byte) []byte {
+	result := make([]byte, 0, len(wire)-(to-from)+len(inserted))
+	result = append(result, wire[:from]...)
+	result = append(result, inserted...)
+	return append(result, wire[to:]...)
+}
+
+func byteRange(from, to int) string {
+	if to-from == 1 {
+		return fmt.Sprintf("Byte %v", from)
+	}
+	return fmt.Sprintf("Bytes %v-%v", from, to-1)
+}
+
+var byteOperators = map[string]ByteOperator{"bitflip": Bitflip, "insert": InsertBytes, "delete": DeleteBytes, "duplicate": DuplicateBytes}
+
+// ParseByteOperators reads a comma-separated list like bitflip,insert,delete,duplicate
+func ParseByteOperators(names string) ([]ByteOperator, error) {
+	result := []ByteOperator{}
+	for _, name := range strings.Split(names, ",") {
+		op, ok := byteOperators[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown byte operator: '%v'", name)
+		}
+		result = append(result, op)
+	}
+	return result, nil
+}
+
+// CountByteMutants counts the mutants of MutateBytes without building them
+func CountByteMutants(rq http.Request, ops []ByteOperator, count int) int {
+	if len(rq.Serialize()) < 2 {
+		return 0
+	}
+	return len(ops) * count
+}
+
+// MutateBytes applies each operator count times to the serialized request, at positions picked
+// from the seed. The mutants are meant to be sent with http.Request.SendRaw, which sends their Wire.
+func MutateBytes(rq http.Request, ops []ByteOperator, count int, seed int64) []Mutant {
+	wire := rq.Serialize()
+	if len(wire) < 2 {
+		return []Mutant{}
+	}
+	rng := rand.New(rand.NewSource(seed))
+	result := []Mutant{}
+	for _, op := range ops {
+		for i := 0; i < count; i++ {
+			mutated, at := op.apply(rng, wire)
+			mutRq := rq.Clone()
+			mutRq.Wire = mutated
+			result = append(result, Mutant{Request: mutRq, Mutation: op.name, Mutable: at, Tag: "bytes"})
+		}
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+package mutation
+
+import (
+	"bytes"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"math/rand"
+	"testing"
+)
+
+func TestEachByteOperatorChangesTheRequest(t *testing.T) {
+	wire := []byte("POST /login HTTP/1.1\r\nHost: localhost\r\nContent-Length: 7\r\n\r\nuser=me")
+	rng := rand.New(rand.NewSource(1))
+
+	for _, op := range []ByteOperator{Bitflip, InsertBytes, DeleteBytes, DuplicateBytes} {
+		for i := 0; i < 100; i++ {
+			got, _ := op.apply(rng, wire)
+
+			testutils.AssertFalse(t, bytes.Equal(got, wire))
+			testutils.AssertTrue(t, len(got) > 0)
+		}
+	}
+}
+
+func TestDeleteBytesLeavesSomethingToSend(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		got, at := deleteBytes(rng, []byte("ab"))
+
+		testutils.AssertLen(t, got, 1)
+		testutils.AssertTrue(t, at == "Byte 0" || at == "Byte 1")
+	}
+}
+
+func TestMutateBytes(t *testing.T) {
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	ops, err := ParseByteOperators("bitflip,duplicate")
+
+	got := MutateBytes(rq, ops, 3, 42)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 6)
+	testutils.AssertEquals(t, got[0].Mutation, "Bitflip")
+	testutils.AssertEquals(t, got[5].Mutation, "DuplicateBytes")
+	testutils.AssertEquals(t, got[0].Tag, "bytes")
+	testutils.AssertByteEquals(t, got[0].WireBytes(), got[0].Wire)
+	testutils.AssertByteEquals(t, MutateBytes(rq, ops, 3, 42)[4].Wire, got[4].Wire)
+	testutils.AssertTrue(t, rq.Wire == nil)
+	testutils.AssertEquals(t, CountByteMutants(rq, ops, 3), len(got))
+}
+
+func TestParseUnknownByteOperator(t *testing.T) {
+	_, err := ParseByteOperators("bitflip,shuffle")
+
+	testutils.AssertTrue(t, err != nil)
+}
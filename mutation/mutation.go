@@ -1,77 +1,111 @@
 package mutation
 
 import (
+	"fmt"
 	"github.com/kamil-s-solecki/haze/http"
 	"github.com/kamil-s-solecki/haze/mutable"
 	"strings"
 )
 
 type Mutation struct {
-	name  string
-	apply func(http.Request, mutable.Mutable) []http.Request
+	name    string
+	tag     string
+	apply   func(http.Request, mutable.Mutable) []http.Request
+	payload string
 }
 
-var SingleQuotes = Mutation{"SingleQuotes", singleQuotes}
+type Mutant struct {
+	http.Request
+	Mutation string
+	Mutable  string
+	Payload  string
+	Tag      string
+	Charset  string
+}
+
+func (m Mutant) String() string {
+	result := m.Mutation + " @ " + m.Mutable
+	if m.Payload != "" {
+		result = fmt.Sprintf("%v %q @ %v", m.Mutation, m.Payload, m.Mutable)
+	}
+	if m.Charset != "" {
+		result += " in " + m.Charset
+	}
+	return result
+}
+
+// InCharset re-encodes the body of the mutant, see http.Request.WithCharset
+func InCharset(mut Mutant, charset string) (Mutant, error) {
+	rq, err := mut.Request.WithCharset(charset)
+	if err != nil {
+		return mut, err
+	}
+	mut.Request = rq
+	mut.Charset = charset
+	return mut, nil
+}
+
+var SingleQuotes = Mutation{name: "SingleQuotes", tag: "sqli", apply: singleQuotes}
 
 func singleQuotes(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "'")
 }
 
-var DoubleQuotes = Mutation{"DoubleQuotes", doubleQuotes}
+var DoubleQuotes = Mutation{name: "DoubleQuotes", tag: "sqli", apply: doubleQuotes}
 
 func doubleQuotes(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "\"")
 }
 
-var SstiFuzz = Mutation{"SstiFuzz", sstiFuzz}
+var SstiFuzz = Mutation{name: "SstiFuzz", tag: "ssti", apply: sstiFuzz}
 
 func sstiFuzz(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "${{<%[%'\"}}%\\.")
 }
 
-var Negative = Mutation{"Negative", negative}
+var Negative = Mutation{name: "Negative", tag: "numeric", apply: negative}
 
 func negative(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return prefixMutation(rq, mutable, "-")
 }
 
-var MinusOne = Mutation{"MinusOne", minusOne}
+var MinusOne = Mutation{name: "MinusOne", tag: "numeric", apply: minusOne}
 
 func minusOne(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "-1")
 }
 
-var TimesSeven = Mutation{"TimesSeven", timesSeven}
+var TimesSeven = Mutation{name: "TimesSeven", tag: "numeric", apply: timesSeven}
 
 func timesSeven(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "*7")
 }
 
-var Brackets = Mutation{"Brackets", brackets}
+var Brackets = Mutation{name: "Brackets", tag: "syntax", apply: brackets}
 
 func brackets(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, ")]}>")
 }
 
-var Backtick = Mutation{"Backtick", backtick}
+var Backtick = Mutation{name: "Backtick", tag: "cmdi", apply: backtick}
 
 func backtick(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "`")
 }
 
-var Comma = Mutation{"Comma", comma}
+var Comma = Mutation{name: "Comma", tag: "syntax", apply: comma}
 
 func comma(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, ",")
 }
 
-var Arraize = Mutation{"Arraize", arraize}
+var Arraize = Mutation{name: "Arraize", tag: "type-juggling", apply: arraize}
 
 func arraize(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "[]")
 }
 
-var TwentyTimes = Mutation{"TwentyTimes", twentyTimes}
+var TwentyTimes = Mutation{name: "TwentyTimes", tag: "overflow", apply: twentyTimes}
 
 func twentyTimes(rq http.Request, mutable mutable.Mutable) []http.Request {
 	trans := func(val string) string {
@@ -80,31 +114,31 @@ func twentyTimes(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return mutable.Apply(rq, trans)
 }
 
-var Nullbyte = Mutation{"Nullbyte", nullbyte}
+var Nullbyte = Mutation{name: "Nullbyte", tag: "nullbyte", apply: nullbyte}
 
 func nullbyte(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return prefixMutation(rq, mutable, "\x00")
 }
 
-var DotDotSlash = Mutation{"DotDotSlash", dotDotSlash}
+var DotDotSlash = Mutation{name: "DotDotSlash", tag: "path-traversal", apply: dotDotSlash}
 
 func dotDotSlash(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "/../../idontexist.txt")
 }
 
-var XmlEscape = Mutation{"XmlEscape", xmlEscape}
+var XmlEscape = Mutation{name: "XmlEscape", tag: "xml", apply: xmlEscape}
 
 func xmlEscape(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, `"><foons:Foo "`)
 }
 
-var Whitespaces = Mutation{"Whitespaces", whitespaces}
+var Whitespaces = Mutation{name: "Whitespaces", tag: "syntax", apply: whitespaces}
 
 func whitespaces(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return prefixMutation(rq, mutable, " \t\f\r\n")
 }
 
-var SemicolonCsv = Mutation{"SemicolonCsv", semicolonCsv}
+var SemicolonCsv = Mutation{name: "SemicolonCsv", tag: "csv", apply: semicolonCsv}
 
 func semicolonCsv(rq http.Request, mutable mutable.Mutable) []http.Request {
 	trans := func(val string) string {
@@ -113,7 +147,7 @@ func semicolonCsv(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return mutable.Apply(rq, trans)
 }
 
-var Colon = Mutation{"Colon", colon}
+var Colon = Mutation{name: "Colon", tag: "syntax", apply: colon}
 
 func colon(rq http.Request, mutable mutable.Mutable) []http.Request {
 	trans := func(val string) string {
@@ -122,19 +156,19 @@ func colon(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return mutable.Apply(rq, trans)
 }
 
-var NeNosqli = Mutation{"NeNosqli", neNosqli}
+var NeNosqli = Mutation{name: "NeNosqli", tag: "nosqli", apply: neNosqli}
 
 func neNosqli(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "[$ne]")
 }
 
-var BrokenRegexNosqli = Mutation{"BrokenRegexNosqli", brokenRegexNosqli}
+var BrokenRegexNosqli = Mutation{name: "BrokenRegexNosqli", tag: "nosqli", apply: brokenRegexNosqli}
 
 func brokenRegexNosqli(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return suffixMutation(rq, mutable, "[$regex]=[(^")
 }
 
-var JsonNeNosqli = Mutation{"JsonNeNosqli", jsonNeNosqli}
+var JsonNeNosqli = Mutation{name: "JsonNeNosqli", tag: "nosqli", apply: jsonNeNosqli}
 
 func jsonNeNosqli(rq http.Request, mutable mutable.Mutable) []http.Request {
 	trans := func(val string) string {
@@ -143,7 +177,7 @@ func jsonNeNosqli(rq http.Request, mutable mutable.Mutable) []http.Request {
 	return mutable.Apply(rq, trans)
 }
 
-var JsonBrokenRegexNosqli = Mutation{"JsonBrokenRegexNosqli", jsonBrokenRegexNosqli}
+var JsonBrokenRegexNosqli = Mutation{name: "JsonBrokenRegexNosqli", tag: "nosqli", apply: jsonBrokenRegexNosqli}
 
 func jsonBrokenRegexNosqli(rq http.Request, mutable mutable.Mutable) []http.Request {
 	trans := func(val string) string {
@@ -152,6 +186,13 @@ func jsonBrokenRegexNosqli(rq http.Request, mutable mutable.Mutable) []http.Requ
 	return mutable.Apply(rq, trans)
 }
 
+// CrlfInjection is only used in CRLF mode, see mutable.AllowCrlf
+var CrlfInjection = Mutation{name: "CrlfInjection", tag: "crlf", apply: crlfInjection}
+
+func crlfInjection(rq http.Request, mutable mutable.Mutable) []http.Request {
+	return suffixMutation(rq, mutable, "\r\nX-Haze-Injected:1")
+}
+
 func suffixMutation(rq http.Request, mutable mutable.Mutable, suffix string) []http.Request {
 	trans := func(val string) string {
 		return val + suffix
@@ -166,6 +207,20 @@ func prefixMutation(rq http.Request, mutable mutable.Mutable, prefix string) []h
 	return mutable.Apply(rq, trans)
 }
 
+// Compatible reports whether the payload makes sense in the mutable. Payloads with CR or LF
+// would be rejected or mangled in header and cookie values, so they are skipped there.
+func Compatible(payload string, mtbl mutable.Mutable) bool {
+	if mutable.AllowCrlf || !strings.ContainsAny(payload, "\r\n") {
+		return true
+	}
+	switch mtbl.Name {
+	case mutable.Header.Name, mutable.Cookie.Name:
+		return false
+	default:
+		return true
+	}
+}
+
 func canApply(mutation Mutation, mtbl mutable.Mutable) bool {
 	switch mutation.name {
 	case JsonNeNosqli.name, JsonBrokenRegexNosqli.name:
@@ -189,6 +244,13 @@ func canApply(mutation Mutation, mtbl mutable.Mutable) bool {
 		default:
 			return true
 		}
+	case CrlfInjection.name:
+		switch mtbl.Name {
+		case mutable.Header.Name, mutable.Cookie.Name:
+			return true
+		default:
+			return false
+		}
 	case Whitespaces.name:
 		switch mtbl.Name {
 		case mutable.Header.Name:
@@ -206,20 +268,36 @@ func canApply(mutation Mutation, mtbl mutable.Mutable) bool {
 	}
 }
 
-func Mutate(rq http.Request, mutations []Mutation, mutables []mutable.Mutable) []http.Request {
-	result := []http.Request{}
+func Mutate(rq http.Request, mutations []Mutation, mutables []mutable.Mutable) []Mutant {
+	result := []Mutant{}
 	for _, mutation := range mutations {
 		for _, mutable := range mutables {
-			if !canApply(mutation, mutable) {
+			if !canApply(mutation, mutable) || !Compatible(mutation.payload, mutable) {
 				continue
 			}
-			mrq := mutation.apply(rq, mutable)
-			result = append(result, mrq...)
+			for _, mrq := range mutation.apply(rq, mutable) {
+				result = append(result, Mutant{Request: mrq, Mutation: mutation.name, Mutable: mutable.Name, Payload: mutation.payload, Tag: mutation.tag})
+			}
 		}
 	}
 	return result
 }
 
+// Count counts the mutants of Mutate without building them. Each mutation applies to every slot
+// of a mutable it can apply to, so the mutables are only applied once.
+func Count(rq http.Request, mutations []Mutation, mutables []mutable.Mutable) int {
+	count := 0
+	for _, mtbl := range mutables {
+		slots := len(replaceMutation(rq, mtbl, ""))
+		for _, mutation := range mutations {
+			if canApply(mutation, mtbl) && Compatible(mutation.payload, mtbl) {
+				count += slots
+			}
+		}
+	}
+	return count
+}
+
 func AllMutations() []Mutation {
 	return []Mutation{SingleQuotes, DoubleQuotes, SstiFuzz, Negative, MinusOne,
 		TimesSeven, Brackets, Backtick, Comma, Arraize, TwentyTimes, Nullbyte,
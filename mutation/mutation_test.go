@@ -1,6 +1,7 @@
 package mutation
 
 import (
+	"encoding/base64"
 	"github.com/kamil-s-solecki/haze/http"
 	"github.com/kamil-s-solecki/haze/mutable"
 	"github.com/kamil-s-solecki/haze/testutils"
@@ -15,6 +16,19 @@ func TestEmpty(t *testing.T) {
 	testutils.AssertEmpty(t, got)
 }
 
+func TestCountMatchesMutate(t *testing.T) {
+	rqs := []http.Request{
+		http.Parse([]byte("GET /a/b?foo=bar&x=eyJpZCI6MX0= HTTP/1.1\r\nHost: localhost\r\nX-Foo: foo\r\nCookie: sid=1; js={\"a\":1}\r\n\r\n")),
+		http.Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/json\r\nContent-Length: 26\r\n\r\n{\"a\":[1,{\"b\":\"c\"}],\"d\":2}")),
+		http.Parse([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 7\r\n\r\na=1&b=2")),
+	}
+	mutations := append(AllMutations(), CrlfInjection)
+
+	for _, rq := range rqs {
+		testutils.AssertEquals(t, Count(rq, mutations, mutable.AllMutatables()), len(Mutate(rq, mutations, mutable.AllMutatables())))
+	}
+}
+
 func TestApplySingleQuotesMutationToPath(t *testing.T) {
 	rq := http.Parse([]byte("GET /somepath HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
 
@@ -256,6 +270,45 @@ func TestApplySingleQuotesMutationToANestedJsonInNestedArrayJsonParameter(t *tes
 	testutils.AssertByteEquals(t, got[0].Body, []byte(`{"foo":[{"bar":"baz'"}]}`))
 }
 
+func TestApplySingleQuotesMutationToJsonParameterOfPatchWithoutContentType(t *testing.T) {
+	rq := http.Parse([]byte("PATCH /users/1 HTTP/1.1\r\nHost: localhost\r\nContent-Length: 14\r\n\r\n{\"name\":\"bob\"}"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.JsonParameter})
+
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, got[0].Method, "PATCH")
+	testutils.AssertByteEquals(t, got[0].Body, []byte(`{"name":"bob'"}`))
+}
+
+func TestApplySingleQuotesMutationToTopLevelJsonArray(t *testing.T) {
+	rq := http.Parse([]byte("POST /auth HTTP/1.1\r\nContent-Type: application/json\r\n\r\n[\"a\",{\"b\":\"c\"}]"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.JsonParameter})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertByteEquals(t, got[0].Body, []byte(`["a'",{"b":"c"}]`))
+	testutils.AssertByteEquals(t, got[1].Body, []byte(`["a",{"b":"c'"}]`))
+}
+
+func TestNoJsonMutantsOfOtherBodies(t *testing.T) {
+	rq := http.Parse([]byte("POST / HTTP/1.1\r\nContent-Type: text/plain\r\n\r\n[1,2]"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.JsonParameter})
+
+	testutils.AssertLen(t, got, 0)
+}
+
+func TestApplySingleQuotesMutationToEachElementOfNestedJsonArrays(t *testing.T) {
+	rq := http.Parse([]byte("POST /auth HTTP/1.1\r\nContent-Type: application/json\r\n\r\n{\"foo\":[[\"a\",\"b\"],[\"c\"]]}"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.JsonParameter})
+
+	testutils.AssertLen(t, got, 3)
+	testutils.AssertByteEquals(t, got[0].Body, []byte(`{"foo":[["a'","b"],["c"]]}`))
+	testutils.AssertByteEquals(t, got[1].Body, []byte(`{"foo":[["a","b'"],["c"]]}`))
+	testutils.AssertByteEquals(t, got[2].Body, []byte(`{"foo":[["a","b"],["c'"]]}`))
+}
+
 func TestApplySingleQuotesMutationToANestedArrayJsonNumericParameter(t *testing.T) {
 	rq := http.Parse([]byte("POST /auth HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: 13\r\n\r\n{\"foo\":[123]}"))
 
@@ -292,6 +345,25 @@ func TestApplySingleQuotesMutationToJsonParameterInCookie(t *testing.T) {
 	testutils.AssertEquals(t, got[0].Cookies["foo"], "{%22bar%22:%22baz'%22}")
 }
 
+func TestCookieMutantsOfJsonAndPlainCookies(t *testing.T) {
+	rq := http.Parse([]byte("GET / HTTP/1.1\r\nCookie: session={\"user\":{\"id\":1,\"roles\":[\"guest\"]}}; theme=dark; n=1\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.Cookie, mutable.CookieJsonParameter})
+
+	testutils.AssertLen(t, got, 5)
+	testutils.AssertEquals(t, got[3].Cookies["session"], "{%22user%22:{%22id%22:%221'%22,%22roles%22:[%22guest%22]}}")
+	testutils.AssertEquals(t, got[4].Cookies["session"], "{%22user%22:{%22id%22:1,%22roles%22:[%22guest'%22]}}")
+}
+
+func TestApplySingleQuotesMutationToJsonArrayCookie(t *testing.T) {
+	rq := http.Parse([]byte("GET / HTTP/1.1\r\nCookie: ids=[1,2]\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.CookieJsonParameter})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[1].Cookies["ids"], "[1,%222'%22]")
+}
+
 func TestApplyBracketsMutationToHeader(t *testing.T) {
 	rq := http.Parse([]byte("GET /somepath HTTP/1.1\r\nFoo: bar\r\n\r\n"))
 
@@ -433,6 +505,40 @@ func TestNotApplyWhitespacesToHeaders(t *testing.T) {
 	testutils.AssertLen(t, got, 0)
 }
 
+func TestCrlfInjection(t *testing.T) {
+	defer func() { mutable.AllowCrlf = false }()
+	mutable.AllowCrlf = true
+	rq := http.Parse([]byte("GET /?foo=bar HTTP/1.1\r\nHost: localhost\r\nX-Foo: foo\r\nCookie: sid=1\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{CrlfInjection}, []mutable.Mutable{mutable.Parameter, mutable.Header, mutable.Cookie})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Headers["X-Foo"], "foo\r\nX-Haze-Injected:1")
+	testutils.AssertEquals(t, got[1].Cookies["sid"], "1\r\nX-Haze-Injected:1")
+	testutils.AssertEquals(t, string(got[1].WireBytes()),
+		"GET /?foo=bar HTTP/1.1\r\nHost: localhost\r\nX-Foo: foo\r\nCookie: sid=1\r\nX-Haze-Injected:1\r\n\r\n")
+}
+
+func TestCrlfInjectionKeepsEncodedCrlfOfCookie(t *testing.T) {
+	defer func() { mutable.AllowCrlf = false }()
+	mutable.AllowCrlf = true
+	rq := http.Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nCookie: sid=a%0d%0ab\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{CrlfInjection}, []mutable.Mutable{mutable.Cookie})
+
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, got[0].Cookies["sid"], "a%250d%250ab\r\nX-Haze-Injected:1")
+}
+
+func TestCookieCrlfIsEncodedOutsideCrlfMode(t *testing.T) {
+	rq := http.Parse([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nCookie: sid=1\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{Whitespaces}, []mutable.Mutable{mutable.Cookie})
+
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, got[0].Cookies["sid"], "%20%09%0c%0d%0a1")
+}
+
 func TestSemicolonCsv(t *testing.T) {
 	rq := http.Parse([]byte("GET /somepath?foo=bar HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
 	got := Mutate(rq, []Mutation{SemicolonCsv}, []mutable.Mutable{mutable.Parameter})
@@ -492,3 +598,106 @@ func TestApplyJsonBorkenRegexNosqliMutationToJsonParameter(t *testing.T) {
 	testutils.AssertLen(t, got, 1)
 	testutils.AssertByteEquals(t, got[0].Body, []byte("{\"foo\":{\"$regex\":\"[(^bar\"}}"))
 }
+
+func TestApplyDoubleQuotesMutationToDuplicatedParameters(t *testing.T) {
+	rq := http.Parse([]byte("GET /somepath?foo=bar&foo=bar HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{DoubleQuotes}, []mutable.Mutable{mutable.Parameter})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Query, "foo=bar%22&foo=bar")
+	testutils.AssertEquals(t, got[1].Query, "foo=bar&foo=bar%22")
+}
+
+func TestApplyDoubleQuotesMutationToParameterWithoutValue(t *testing.T) {
+	rq := http.Parse([]byte("GET /somepath?foo&bar=baz HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{DoubleQuotes}, []mutable.Mutable{mutable.Parameter})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Query, "foo=%22&bar=baz")
+	testutils.AssertEquals(t, got[1].Query, "foo&bar=baz%22")
+}
+
+func TestApplySingleQuotesMutationToPathSegments(t *testing.T) {
+	rq := http.Parse([]byte("GET /api/users/123?foo=bar HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.PathSegment})
+
+	testutils.AssertLen(t, got, 3)
+	testutils.AssertEquals(t, got[0].RequestUri, "/api'/users/123?foo=bar")
+	testutils.AssertEquals(t, got[1].RequestUri, "/api/users'/123?foo=bar")
+	testutils.AssertEquals(t, got[2].RequestUri, "/api/users/123'?foo=bar")
+}
+
+func TestDoNothingForSingleSegmentPath(t *testing.T) {
+	rq := http.Parse([]byte("GET /somepath HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.PathSegment})
+
+	testutils.AssertLen(t, got, 0)
+}
+
+func TestApplySingleQuotesMutationToXmlValues(t *testing.T) {
+	rq := http.Parse([]byte("POST /api HTTP/1.1\r\nContent-Type: application/xml\r\n\r\n<user id=\"1\"><name>bob</name></user>"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.XmlValue})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, string(got[0].Body), "<user id=\"1'\"><name>bob</name></user>")
+	testutils.AssertEquals(t, string(got[1].Body), "<user id=\"1\"><name>bob'</name></user>")
+}
+
+func TestApplySingleQuotesMutationToGraphqlVariables(t *testing.T) {
+	rq := http.Parse([]byte("POST /graphql HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" +
+		`{"query":"query($id: ID!, $f: Filter) { user(id: $id) { name } }","variables":{"id":"42","f":{"name":"bob"}}}`))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.GraphqlVariable, mutable.JsonParameter})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, string(got[0].Body), `{"query":"query($id: ID!, $f: Filter) { user(id: $id) { name } }","variables":{"f":{"name":"bob'"},"id":"42"}}`)
+	testutils.AssertEquals(t, string(got[1].Body), `{"query":"query($id: ID!, $f: Filter) { user(id: $id) { name } }","variables":{"f":{"name":"bob"},"id":"42'"}}`)
+}
+
+func TestApplySingleQuotesMutationToGraphqlQuery(t *testing.T) {
+	rq := http.Parse([]byte("POST /graphql HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" +
+		`{"query":"{ me { id } }","variables":{"id":"42"}}`))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.GraphqlQuery})
+
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, string(got[0].Body), `{"query":"{ me { id } }'","variables":{"id":"42"}}`)
+}
+
+func TestApplySingleQuotesMutationToBase64Parameter(t *testing.T) {
+	rq := http.Parse([]byte("GET /?data=eyJpZCI6MX0%3D&page=2 HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.Base64Parameter})
+
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, got[0].Query, "data=eyJpZCI6MX0n&page=2")
+	testutils.AssertEquals(t, got[0].Mutable, "Base64Parameter")
+}
+
+func TestApplySingleQuotesMutationToUrlSafeBase64Cookie(t *testing.T) {
+	rq := http.Parse([]byte("GET / HTTP/1.1\r\nHost:www.example.com\r\nCookie: state=PD8_Pz4-\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes}, []mutable.Mutable{mutable.Base64Cookie})
+
+	testutils.AssertLen(t, got, 1)
+	decoded, _ := base64.URLEncoding.DecodeString(got[0].Cookies["state"])
+	testutils.AssertEquals(t, string(decoded), "<???>>'")
+}
+
+func TestBuiltInMutationsAreTagged(t *testing.T) {
+	rq := http.Parse([]byte("GET /somepath HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := Mutate(rq, []Mutation{SingleQuotes, DotDotSlash}, []mutable.Mutable{mutable.Path})
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Tag, "sqli")
+	testutils.AssertEquals(t, got[1].Tag, "path-traversal")
+	for _, m := range AllMutations() {
+		testutils.AssertTrue(t, m.tag != "")
+	}
+}
@@ -0,0 +1,96 @@
+package mutation
+
+import (
+	"bufio"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/mutable"
+	"os"
+	"regexp"
+	"strings"
+)
+
+func PayloadMutation(payload string) Mutation {
+	apply := func(rq http.Request, mutable mutable.Mutable) []http.Request {
+		return replaceMutation(rq, mutable, payload)
+	}
+	return Mutation{name: "Payload", apply: apply, payload: payload}
+}
+
+func replaceMutation(rq http.Request, mutable mutable.Mutable, payload string) []http.Request {
+	trans := func(val string) string {
+		return payload
+	}
+	return mutable.Apply(rq, trans)
+}
+
+// ReadPayloads streams the wordlist line by line, so that big files are never loaded at once.
+// It stops early when each returns false. Empty lines and lines starting with # are skipped,
+// a payload starting with # is written as \#. Other whitespace is part of the payload.
+// A #tag: sqli line tags the payloads following it, up to the next #tag: line. #tag: alone
+// leaves the following payloads untagged.
+func ReadPayloads(path string, each func(Mutation) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	tag := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := tagDirective.FindStringSubmatch(line); m != nil {
+			tag = m[1]
+			continue
+		}
+		payload, ok := parsePayloadLine(line)
+		if !ok {
+			continue
+		}
+		mutation := PayloadMutation(payload)
+		mutation.tag = tag
+		if !each(mutation) {
+			break
+		}
+	}
+	return scanner.Err()
+}
+
+var tagDirective = regexp.MustCompile(`^#tag:\s*([a-z0-9_-]*)\s*$`)
+
+func parsePayloadLine(line string) (payload string, ok bool) {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+	if strings.HasPrefix(line, `\#`) {
+		return line[1:], true
+	}
+	return line, true
+}
+
+func CountPayloads(path string) (int, error) {
+	count := 0
+	err := ReadPayloads(path, func(Mutation) bool {
+		count++
+		return true
+	})
+	return count, err
+}
+
+// CountMutants counts the mutants of the wordlist payloads without building them. Payloads
+// only differ in the mutables they are compatible with, see Compatible.
+func CountMutants(rq http.Request, path string, mtbls []mutable.Mutable) (int, error) {
+	perPayload := len(Mutate(rq, []Mutation{PayloadMutation("")}, mtbls))
+	perCrlfPayload := len(Mutate(rq, []Mutation{PayloadMutation("\n")}, mtbls))
+	count := 0
+	err := ReadPayloads(path, func(m Mutation) bool {
+		if strings.ContainsAny(m.payload, "\r\n") {
+			count += perCrlfPayload
+		} else {
+			count += perPayload
+		}
+		return true
+	})
+	return count, err
+}
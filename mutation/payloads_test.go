@@ -0,0 +1,134 @@
+package mutation
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/mutable"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeWordlist(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "payloads.txt")
+	os.WriteFile(path, []byte(content), 0644)
+	return path
+}
+
+func TestEachPayloadLineBecomesMutation(t *testing.T) {
+	path := writeWordlist(t, "' OR 1=1--\n\n<script>\n../../etc/passwd\n")
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := []Mutant{}
+	err := ReadPayloads(path, func(m Mutation) bool {
+		got = append(got, Mutate(rq, []Mutation{m}, []mutable.Mutable{mutable.Parameter})...)
+		return true
+	})
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 3)
+	testutils.AssertEquals(t, got[0].Query, "id='%20OR%201=1--")
+	testutils.AssertEquals(t, got[1].Query, "id=<script>")
+	testutils.AssertEquals(t, got[2].Query, "id=../../etc/passwd")
+	testutils.AssertEquals(t, got[1].String(), `Payload "<script>" @ Parameter`)
+}
+
+func TestReadPayloadsStopsEarly(t *testing.T) {
+	path := writeWordlist(t, "a\nb\nc\n")
+
+	read := 0
+	ReadPayloads(path, func(m Mutation) bool {
+		read++
+		return read < 2
+	})
+
+	testutils.AssertEquals(t, read, 2)
+}
+
+func TestSkipCommentsAndBlankLines(t *testing.T) {
+	path := writeWordlist(t, "# sqli\n' OR 1=1--\n\n#xss\n\\#fragment\n\\\\#\n  \ntrailing \t\n")
+
+	got := []string{}
+	err := ReadPayloads(path, func(m Mutation) bool {
+		got = append(got, m.payload)
+		return true
+	})
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, strings.Join(got, "|"), "' OR 1=1--|#fragment|\\\\#|  |trailing \t")
+}
+
+func TestCountPayloads(t *testing.T) {
+	count, err := CountPayloads(writeWordlist(t, "a\n\n# comment\nb\nc"))
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, count, 3)
+}
+
+func TestCrlfPayloadIsSkippedForHeadersAndCookies(t *testing.T) {
+	rq := http.Parse([]byte("POST / HTTP/1.1\r\nHost: www.example.com\r\nX-Foo: bar\r\nContent-Type: application/x-www-form-urlencoded\r\nCookie: sid=1\r\n\r\na=1"))
+	payload := PayloadMutation("x\r\nSet-Cookie: injected=1")
+	mtbls := []mutable.Mutable{mutable.Header, mutable.Cookie, mutable.BodyParameter}
+
+	got := Mutate(rq, []Mutation{payload}, mtbls)
+
+	testutils.AssertLen(t, got, 1)
+	testutils.AssertEquals(t, got[0].Mutable, mutable.BodyParameter.Name)
+	testutils.AssertFalse(t, Compatible(payload.payload, mutable.Cookie))
+	testutils.AssertTrue(t, Compatible(payload.payload, mutable.BodyParameter))
+	testutils.AssertTrue(t, Compatible("x", mutable.Cookie))
+}
+
+func TestCountMutantsSkipsCrlfPayloadsForHeadersAndCookies(t *testing.T) {
+	path := writeWordlist(t, "a\nx\rSet-Cookie: injected=1\nb\n")
+	rq := http.Parse([]byte("POST / HTTP/1.1\r\nHost: www.example.com\r\nX-Foo: bar\r\nContent-Type: application/x-www-form-urlencoded\r\nCookie: sid=1\r\n\r\na=1"))
+	mtbls := []mutable.Mutable{mutable.Header, mutable.Cookie, mutable.BodyParameter}
+
+	got := []Mutant{}
+	ReadPayloads(path, func(m Mutation) bool {
+		got = append(got, Mutate(rq, []Mutation{m}, mtbls)...)
+		return true
+	})
+	count, err := CountMutants(rq, path, mtbls)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, count, len(got))
+	testutils.AssertEquals(t, count, 2*len(Mutate(rq, []Mutation{PayloadMutation("")}, mtbls))+1)
+}
+
+func TestTaggedPayloads(t *testing.T) {
+	path := writeWordlist(t, "#tag: sqli\n' OR 1=1--\n#tag:path-traversal\n../../etc/passwd\n#tag:\njavascript:alert(1)\nplain\n")
+	rq := http.Parse([]byte("GET /?id=1 HTTP/1.1\r\nHost:www.example.com\r\n\r\n"))
+
+	got := []Mutant{}
+	err := ReadPayloads(path, func(m Mutation) bool {
+		got = append(got, Mutate(rq, []Mutation{m}, []mutable.Mutable{mutable.Parameter})...)
+		return true
+	})
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 4)
+	testutils.AssertEquals(t, got[0].Tag, "sqli")
+	testutils.AssertEquals(t, got[0].Payload, "' OR 1=1--")
+	testutils.AssertEquals(t, got[1].Tag, "path-traversal")
+	testutils.AssertEquals(t, got[1].Payload, "../../etc/passwd")
+	testutils.AssertEquals(t, got[2].Tag, "")
+	testutils.AssertEquals(t, got[2].Payload, "javascript:alert(1)")
+	testutils.AssertEquals(t, got[3].Tag, "")
+	testutils.AssertEquals(t, got[3].Payload, "plain")
+}
+
+func TestColonsAreKeptInPayloads(t *testing.T) {
+	path := writeWordlist(t, "http://169.254.169.254/\njavascript:alert(1)\nfile:///etc/passwd\na:\nhttp://x\n")
+
+	got := []string{}
+	err := ReadPayloads(path, func(m Mutation) bool {
+		testutils.AssertEquals(t, m.tag, "")
+		got = append(got, m.payload)
+		return true
+	})
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, strings.Join(got, " "), "http://169.254.169.254/ javascript:alert(1) file:///etc/passwd a: http://x")
+}
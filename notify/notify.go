@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kamil-s-solecki/haze/logging"
+	"github.com/kamil-s-solecki/haze/report"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var Retries = 3
+
+var RetryDelay = time.Second
+
+// client is kept apart from the fuzzing transport, so that the webhook is not throttled with the
+// target and its certificate is verified
+var client = &http.Client{Timeout: 10 * time.Second}
+
+type Notifier struct {
+	webhook string
+	every   time.Duration
+	mu      sync.Mutex
+	pending []report.Hit
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type digest struct {
+	Text     string       `json:"text"`
+	Findings []report.Hit `json:"findings"`
+}
+
+// Start posts the reported hits to the webhook in digests, at most one every `every`,
+// so that a flood of hits does not spam the channel.
+func Start(webhook string, every time.Duration) (*Notifier, error) {
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid webhook url: %v", webhook)
+	}
+	n := &Notifier{
+		webhook: webhook,
+		every:   every,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go n.loop()
+	return n, nil
+}
+
+func (n *Notifier) Notify(hit report.Hit) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending = append(n.pending, hit)
+}
+
+func (n *Notifier) Close() error {
+	close(n.stop)
+	<-n.done
+	return n.flush()
+}
+
+func (n *Notifier) loop() {
+	defer close(n.done)
+	ticker := time.NewTicker(n.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := n.flush(); err != nil {
+				logging.Warnf("cannot post to the webhook: %v", err)
+			}
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+func (n *Notifier) flush() error {
+	n.mu.Lock()
+	hits := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+
+	if len(hits) == 0 {
+		return nil
+	}
+	return n.post(hits)
+}
+
+func (n *Notifier) post(hits []report.Hit) error {
+	body, err := json.Marshal(digest{summarize(hits), hits})
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err := send(n.webhook, body)
+		if err == nil || attempt >= Retries {
+			return err
+		}
+		logging.Infof("webhook attempt %v of %v failed, retrying: %v", attempt, Retries, err)
+		time.Sleep(RetryDelay)
+	}
+}
+
+func send(webhook string, body []byte) error {
+	res, err := client.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %v", res.StatusCode)
+	}
+	return nil
+}
+
+func summarize(hits []report.Hit) string {
+	lns := []string{fmt.Sprintf("haze: %v new finding(s)", len(hits))}
+	for _, h := range hits {
+		lns = append(lns, fmt.Sprintf("- %v @ %v: %v %v [Code: %v, Len: %v]", h.Mutation, h.Mutable, h.Method, h.Url, h.Code, h.Length))
+	}
+	return strings.Join(lns, "\n")
+}
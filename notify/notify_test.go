@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"encoding/json"
+	"github.com/kamil-s-solecki/haze/report"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type receiver struct {
+	mu      sync.Mutex
+	digests []map[string]interface{}
+	fails   int
+	calls   int
+}
+
+func (rc *receiver) start(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.calls++
+		if rc.fails > 0 {
+			rc.fails--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var d map[string]interface{}
+		json.Unmarshal(body, &d)
+		rc.digests = append(rc.digests, d)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/hooks/abc?token=x"
+}
+
+func hit(i int) report.Hit {
+	return report.Hit{Mutation: "SingleQuotes", Mutable: "Parameter", Method: "GET",
+		Url: "http://localhost/?id=1'", Code: 500 + i, Length: 21, Report: "1.md"}
+}
+
+func TestShouldPostDigestOfHits(t *testing.T) {
+	rc := &receiver{}
+	n, err := Start(rc.start(t), time.Hour)
+	testutils.AssertTrue(t, err == nil)
+
+	for i := 0; i < 5; i++ {
+		n.Notify(hit(i))
+	}
+	err = n.Close()
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, rc.calls, 1)
+	d := rc.digests[0]
+	testutils.AssertTrue(t, strings.HasPrefix(d["text"].(string), "haze: 5 new finding(s)\n- SingleQuotes @ Parameter"))
+	findings := d["findings"].([]interface{})
+	testutils.AssertLen(t, findings, 5)
+	first := findings[0].(map[string]interface{})
+	testutils.AssertEquals(t, first["mutation"].(string), "SingleQuotes")
+	testutils.AssertEquals(t, first["mutable"].(string), "Parameter")
+	testutils.AssertEquals(t, first["url"].(string), "http://localhost/?id=1'")
+	testutils.AssertEquals(t, first["code"].(float64), float64(500))
+}
+
+func TestShouldRetryFailedPosts(t *testing.T) {
+	defer func(d time.Duration) { RetryDelay = d }(RetryDelay)
+	RetryDelay = time.Millisecond
+	rc := &receiver{fails: 2}
+	n, _ := Start(rc.start(t), time.Hour)
+
+	n.Notify(hit(0))
+	err := n.Close()
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertEquals(t, rc.calls, 3)
+	testutils.AssertLen(t, rc.digests, 1)
+}
+
+func TestShouldBatchHitsWithinInterval(t *testing.T) {
+	rc := &receiver{}
+	n, _ := Start(rc.start(t), 50*time.Millisecond)
+
+	for i := 0; i < 20; i++ {
+		n.Notify(hit(i))
+	}
+	time.Sleep(120 * time.Millisecond)
+	n.Notify(hit(20))
+	n.Close()
+
+	total := 0
+	for _, d := range rc.digests {
+		total += len(d["findings"].([]interface{}))
+	}
+	testutils.AssertEquals(t, total, 21)
+	testutils.AssertTrue(t, rc.calls <= 3)
+}
+
+func TestShouldRejectInvalidWebhook(t *testing.T) {
+	_, err := Start("ftp://example.com", time.Second)
+
+	testutils.AssertTrue(t, err != nil)
+}
+
+func TestShouldVerifyWebhookCertificate(t *testing.T) {
+	defer func(d time.Duration) { RetryDelay = d }(RetryDelay)
+	RetryDelay = time.Millisecond
+	calls := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls++ }))
+	defer srv.Close()
+	n, _ := Start(srv.URL, time.Hour)
+
+	n.Notify(hit(0))
+	err := n.Close()
+
+	testutils.AssertTrue(t, err != nil)
+	testutils.AssertEquals(t, calls, 0)
+}
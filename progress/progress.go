@@ -5,16 +5,29 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
+)
+
+const plainSteps = 10
+
+type Mode int
+
+const (
+	Tty Mode = iota
+	Plain
+	Hidden
 )
 
 type Bar struct {
 	curr, total int
+	start       time.Time
+	mode        Mode
 	buff        *bufio.Writer
 	mu          *sync.Mutex
 }
 
-func Start(total int, buff *bufio.Writer, mu *sync.Mutex) Bar {
-	b := Bar{curr: 0, total: total, buff: buff, mu: mu}
+func Start(total int, mode Mode, buff *bufio.Writer, mu *sync.Mutex) Bar {
+	b := Bar{curr: 0, total: total, start: time.Now(), mode: mode, buff: buff, mu: mu}
 	return b
 }
 
@@ -22,12 +35,24 @@ func (b *Bar) Next() {
 	defer b.mu.Unlock()
 	b.mu.Lock()
 	b.curr++
-	b.update()
+	switch b.mode {
+	case Tty:
+		b.update()
+	case Plain:
+		if b.total > 0 && b.curr*plainSteps/b.total != (b.curr-1)*plainSteps/b.total {
+			b.printLine()
+		}
+	}
 }
 
 func (b Bar) update() {
 	defer b.buff.Flush()
-	fmt.Fprint(b.buff, "\033[30D\033[0K", b, "\033[30D")
+	fmt.Fprint(b.buff, "\r\033[0K", b, "\r")
+}
+
+func (b Bar) printLine() {
+	defer b.buff.Flush()
+	fmt.Fprintln(b.buff, b.status())
 }
 
 const spinChars = `|/-\`
@@ -36,19 +61,41 @@ func (b Bar) spinner() byte {
 	return spinChars[b.curr%len(spinChars)]
 }
 
+func (b Bar) status() string {
+	elapsed := time.Since(b.start)
+	return fmt.Sprintf("     [ %v / %v ] %.1f req/s, ETA: %v", b.curr, b.total,
+		rate(b.curr, elapsed), eta(b.curr, b.total, elapsed).Round(time.Second))
+}
+
 func (b Bar) String() string {
-	return fmt.Sprintf("     %c [ %v / %v ] %c", b.spinner(), b.curr, b.total, b.spinner())
+	return fmt.Sprintf("%v %c", b.status(), b.spinner())
 }
 
 func (b Bar) End() {
 	defer b.mu.Unlock()
 	b.mu.Lock()
-	b.clear()
+	if b.mode == Tty {
+		b.clear()
+	}
 }
 
 func (b Bar) clear() {
 	defer b.buff.Flush()
-	spaces := strings.Repeat(" ", len(b.String()))
-	fmt.Fprint(b.buff, "\033[30D\033[0K", spaces, "\033[30D")
+	fmt.Fprint(b.buff, "\r\033[0K", strings.Repeat(" ", len(b.String())), "\r")
 	fmt.Fprint(b.buff, "\n")
 }
+
+func rate(done int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(done) / elapsed.Seconds()
+}
+
+func eta(done, total int, elapsed time.Duration) time.Duration {
+	if done <= 0 || done >= total {
+		return 0
+	}
+	perRequest := elapsed / time.Duration(done)
+	return perRequest * time.Duration(total-done)
+}
@@ -0,0 +1,43 @@
+package progress
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+	"time"
+)
+
+func TestRate(t *testing.T) {
+	cases := []struct {
+		done    int
+		elapsed time.Duration
+		rate    float64
+	}{
+		{0, 0, 0},
+		{0, time.Second, 0},
+		{10, time.Second, 10},
+		{15, 2 * time.Second, 7.5},
+		{5, 500 * time.Millisecond, 10},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, rate(c.done, c.elapsed), c.rate)
+	}
+}
+
+func TestEta(t *testing.T) {
+	cases := []struct {
+		done, total int
+		elapsed     time.Duration
+		eta         time.Duration
+	}{
+		{0, 100, time.Second, 0},
+		{10, 100, time.Second, 9 * time.Second},
+		{50, 100, 10 * time.Second, 10 * time.Second},
+		{99, 100, 99 * time.Second, time.Second},
+		{100, 100, 10 * time.Second, 0},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, eta(c.done, c.total, c.elapsed), c.eta)
+	}
+}
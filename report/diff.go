@@ -0,0 +1,61 @@
+package report
+
+import (
+	"strings"
+)
+
+func Diff(orig, mutated []byte) string {
+	a := splitLines(orig)
+	b := splitLines(mutated)
+	lcs := lcsTable(a, b)
+
+	result := []string{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, "- "+a[i])
+			i++
+		default:
+			result = append(result, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		result = append(result, "+ "+b[j])
+	}
+	return strings.Join(result, "\n")
+}
+
+func splitLines(bs []byte) []string {
+	lns := strings.Split(string(bs), "\n")
+	for i, ln := range lns {
+		lns[i] = strings.TrimSuffix(ln, "\r")
+	}
+	return lns
+}
+
+func lcsTable(a, b []string) [][]int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return lcs
+}
@@ -0,0 +1,30 @@
+package report
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func TestNoDiffForEqualRequests(t *testing.T) {
+	rq := []byte("GET /foo HTTP/1.1\r\nHost: example.com\r\n\r\n")
+
+	testutils.AssertEquals(t, Diff(rq, rq), "")
+}
+
+func TestDiffOnlyMutatedLine(t *testing.T) {
+	orig := []byte("GET /foo?bar=baz HTTP/1.1\r\nHost: example.com\r\nX-Foo: foo\r\n\r\n")
+	mut := []byte("GET /foo?bar=baz' HTTP/1.1\r\nHost: example.com\r\nX-Foo: foo\r\n\r\n")
+
+	got := Diff(orig, mut)
+
+	testutils.AssertEquals(t, got, "- GET /foo?bar=baz HTTP/1.1\n+ GET /foo?bar=baz' HTTP/1.1")
+}
+
+func TestDiffMutatedHeaderAndBody(t *testing.T) {
+	orig := []byte("POST / HTTP/1.1\r\nContent-Length: 7\r\nHost: example.com\r\n\r\nfoo=bar")
+	mut := []byte("POST / HTTP/1.1\r\nContent-Length: 8\r\nHost: example.com\r\n\r\nfoo=bar'")
+
+	got := Diff(orig, mut)
+
+	testutils.AssertEquals(t, got, "- Content-Length: 7\n+ Content-Length: 8\n- foo=bar\n+ foo=bar'")
+}
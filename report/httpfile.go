@@ -0,0 +1,32 @@
+package report
+
+import (
+	"sync"
+)
+
+// HttpFile collects the reported requests into a single .http file
+type HttpFile struct {
+	mu      sync.Mutex
+	entries [][]byte
+}
+
+func NewHttpFile() *HttpFile {
+	return &HttpFile{}
+}
+
+// Add takes an entry rendered by http.Request.ToHttpFile
+func (f *HttpFile) Add(entry []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, entry)
+}
+
+func (f *HttpFile) Write(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bs := []byte{}
+	for _, entry := range f.entries {
+		bs = append(bs, entry...)
+	}
+	return writeFile(path, bs)
+}
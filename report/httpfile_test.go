@@ -0,0 +1,28 @@
+package report
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHttpFileParsesBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hits.http")
+	rq := http.Parse([]byte("POST /?a=1' HTTP/1.1\r\nHost: localhost\r\nContent-Type: text/plain\r\n\r\nfoo"))
+	f := NewHttpFile()
+	f.Add(rq.ToHttpFile("http://localhost:8080", "SingleQuotes Parameter a"))
+	f.Add(rq.WithMethod("PUT").ToHttpFile("http://localhost:8080", "SingleQuotes Parameter a"))
+
+	err := f.Write(path)
+
+	testutils.AssertTrue(t, err == nil)
+	bs, _ := os.ReadFile(path)
+	got := http.ParseHttpFile(bs)
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0].Method, "POST")
+	testutils.AssertEquals(t, got[1].Method, "PUT")
+	testutils.AssertEquals(t, got[1].Url("http://localhost:8080"), "http://localhost:8080/?a=1'")
+	testutils.AssertByteEquals(t, got[1].Body, []byte("foo"))
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/kamil-s-solecki/haze/summary"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Markdown struct {
+	mu   sync.Mutex
+	hits []Hit
+}
+
+func NewMarkdown() *Markdown {
+	return &Markdown{}
+}
+
+func (m *Markdown) Add(hit Hit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hits = append(m.hits, hit)
+}
+
+// Run describes the fuzzing run in the header of the markdown report
+type Run struct {
+	Target  string
+	Command string
+	Stats   *summary.Summary
+}
+
+func (m *Markdown) document(run Run) []byte {
+	m.mu.Lock()
+	hits := append([]Hit{}, m.hits...)
+	m.mu.Unlock()
+	sort.SliceStable(hits, func(i, j int) bool {
+		return reportNumber(hits[i].Report) < reportNumber(hits[j].Report)
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("# Haze report\r\n\r\n")
+	buf.WriteString("## Run\r\n")
+	buf.WriteString("| | |\r\n|---|---|\r\n")
+	fmt.Fprintf(&buf, "| Target | %v |\r\n", cell(run.Target))
+	fmt.Fprintf(&buf, "| Command | `%v` |\r\n", cell(run.Command))
+	fmt.Fprintf(&buf, "| Duration | %v |\r\n", run.Stats.Elapsed().Round(time.Millisecond))
+	buf.WriteString("\r\n")
+
+	buf.WriteString("## Summary\r\n")
+	buf.WriteString("| | |\r\n|---|---|\r\n")
+	fmt.Fprintf(&buf, "| Requests | %v |\r\n", run.Stats.Requests)
+	for class := 2; class <= 5; class++ {
+		fmt.Fprintf(&buf, "| %vxx | %v |\r\n", class, run.Stats.Classes[class])
+	}
+	fmt.Fprintf(&buf, "| Errors | %v |\r\n", run.Stats.Errors)
+	fmt.Fprintf(&buf, "| Reported | %v |\r\n", run.Stats.Reported)
+	buf.WriteString("\r\n")
+
+	buf.WriteString("## Findings\r\n")
+	if len(hits) == 0 {
+		buf.WriteString("Nothing was reported.\r\n")
+		return buf.Bytes()
+	}
+	buf.WriteString("| Status | Length | Method | Url | Mutation | Report |\r\n")
+	buf.WriteString("|---|---|---|---|---|---|\r\n")
+	for _, h := range hits {
+		fmt.Fprintf(&buf, "| %v | %v | %v | %v | %v @ %v | [%v](%v) |\r\n",
+			h.Code, h.Length, h.Method, cell(h.Url), cell(h.Mutation), cell(h.Mutable), h.Report, h.Report)
+	}
+	return buf.Bytes()
+}
+
+func (m *Markdown) Write(path string, run Run) error {
+	return writeFile(path, m.document(run))
+}
+
+func reportNumber(fname string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(fname, ".md"))
+	return n
+}
+
+func cell(val string) string {
+	return strings.NewReplacer("|", `\|`, "\r", "", "\n", " ").Replace(val)
+}
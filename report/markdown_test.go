@@ -0,0 +1,56 @@
+package report
+
+import (
+	"errors"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/summary"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownHasSectionsAndRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	stats := summary.Start()
+	stats.Add(http.Response{Code: 200}, nil, false, "")
+	stats.Add(http.Response{Code: 500}, nil, true, "")
+	stats.Add(http.Response{Code: 502}, nil, true, "")
+	stats.Add(http.Response{}, errors.New("connection refused"), false, "")
+	md := NewMarkdown()
+	md.Add(Hit{"Nullbyte", "Cookie", "nullbyte", "POST", "http://localhost/", 502, 0, "2.md"})
+	md.Add(Hit{"SingleQuotes", "Parameter", "sqli", "GET", "http://localhost/?a=1|2'", 500, 21, "1.md"})
+
+	err := md.Write(path, Run{Target: "http://localhost", Command: "haze -t http://localhost rq.txt", Stats: stats})
+
+	testutils.AssertTrue(t, err == nil)
+	bs, _ := os.ReadFile(path)
+	doc := string(bs)
+	for _, section := range []string{"# Haze report\r\n", "## Run\r\n", "## Summary\r\n", "## Findings\r\n"} {
+		testutils.AssertTrue(t, strings.Contains(doc, section))
+	}
+	testutils.AssertTrue(t, strings.Contains(doc, "| Target | http://localhost |"))
+	testutils.AssertTrue(t, strings.Contains(doc, "| Requests | 4 |"))
+	testutils.AssertTrue(t, strings.Contains(doc, "| Reported | 2 |"))
+
+	findings := strings.Split(doc[strings.Index(doc, "## Findings"):], "\r\n")
+	rows := []string{}
+	for _, ln := range findings {
+		if strings.HasPrefix(ln, "| 5") {
+			rows = append(rows, ln)
+		}
+	}
+	testutils.AssertLen(t, rows, 2)
+	testutils.AssertEquals(t, rows[0], `| 500 | 21 | GET | http://localhost/?a=1\|2' | SingleQuotes @ Parameter | [1.md](1.md) |`)
+	testutils.AssertEquals(t, rows[1], "| 502 | 0 | POST | http://localhost/ | Nullbyte @ Cookie | [2.md](2.md) |")
+}
+
+func TestMarkdownWithoutFindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+
+	NewMarkdown().Write(path, Run{Target: "http://localhost", Stats: summary.Start()})
+
+	bs, _ := os.ReadFile(path)
+	testutils.AssertTrue(t, strings.HasSuffix(string(bs), "## Findings\r\nNothing was reported.\r\n"))
+}
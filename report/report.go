@@ -1,37 +1,91 @@
 package report
 
 import (
+	"bytes"
 	"os"
 	"path"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var curr int64 = 0
 
-func Report(rq []byte, res []byte, dir string) string {
-	curr += 1
-	fname := strconv.FormatInt(curr, 10) + ".md"
-	fullFname := dir + "/" + fname
-	file, err := os.Create(fullFname)
-	if err != nil {
+func Report(mutation, diff string, rq []byte, res []byte, dir string) string {
+	fname := strconv.FormatInt(atomic.AddInt64(&curr, 1), 10) + ".md"
+
+	var buf bytes.Buffer
+	buf.WriteString("# Mutation\r\n")
+	buf.WriteString(mutation + "\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString("# Diff\r\n")
+	buf.WriteString("```diff\r\n")
+	buf.WriteString(diff)
+	buf.WriteString("\r\n```\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString("# Request\r\n")
+	buf.WriteString("```\r\n")
+	buf.Write(rq)
+	buf.WriteString("```\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString("# Response\r\n")
+	buf.WriteString("```\r\n")
+	buf.Write(res)
+	buf.WriteString("\r\n```\r\n")
+
+	if err := writeFile(dir+"/"+fname, buf.Bytes()); err != nil {
 		panic(err)
 	}
-	defer file.Close()
-
-	file.Write([]byte("# Request\r\n"))
-	file.Write([]byte("```\r\n"))
-	file.Write(rq)
-	file.Write([]byte("```\r\n"))
-	file.Write([]byte("\r\n"))
-	file.Write([]byte("# Response\r\n"))
-	file.Write([]byte("```\r\n"))
-	file.Write(res)
-	file.Write([]byte("\r\n```\r\n"))
-
 	return fname
 }
 
+var partial sync.Map
+
+// writeFile writes next to the path first, so that a run killed in the middle of writing
+// leaves either the whole file or a partial one RemovePartial knows of
+func writeFile(path string, data []byte) error {
+	tmp := path + ".part"
+	partial.Store(tmp, true)
+	defer partial.Delete(tmp)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RemovePartial removes the files being written, before the process exits without waiting for them
+func RemovePartial() {
+	partial.Range(func(tmp, _ interface{}) bool {
+		os.Remove(tmp.(string))
+		return true
+	})
+}
+
+const (
+	requestStart  = "# Request\r\n```\r\n"
+	responseStart = "```\r\n\r\n# Response\r\n```\r\n"
+	responseEnd   = "\r\n```\r\n"
+)
+
+// ReadSaved reads the raw request and response back from a file written by Report. Any other file
+// is taken as a raw request with no saved response.
+func ReadSaved(path string) (rq, res []byte, err error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := bytes.Index(bs, []byte(requestStart))
+	mid := bytes.LastIndex(bs, []byte(responseStart))
+	if start == -1 || mid < start {
+		return bs, nil, nil
+	}
+	rq = bs[start+len(requestStart) : mid]
+	res = bytes.TrimSuffix(bs[mid+len(responseStart):], []byte(responseEnd))
+	return rq, res, nil
+}
+
 func MakeReportDir(base string) string {
 	dir := path.Join(base, time.Now().Format("20060102_150405"))
 	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSavedRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	rq := []byte("POST /?a=1' HTTP/1.1\r\nHost: localhost\r\n\r\nbody without a newline")
+	res := []byte("HTTP/1.1 500 Internal Server Error\r\nContent-Length: 5\r\n\r\noops\n")
+
+	fname := Report("SingleQuotes Parameter a", "-a=1\n+a=1'", rq, res, dir)
+	gotRq, gotRes, err := ReadSaved(filepath.Join(dir, fname))
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertByteEquals(t, gotRq, rq)
+	testutils.AssertByteEquals(t, gotRes, res)
+}
+
+func TestReadSavedRawRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rq.txt")
+	rq := []byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	os.WriteFile(path, rq, 0644)
+
+	gotRq, gotRes, err := ReadSaved(path)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertByteEquals(t, gotRq, rq)
+	testutils.AssertTrue(t, gotRes == nil)
+}
+
+func TestReportLeavesNoPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	fname := Report("SingleQuotes Parameter a", "", []byte("GET / HTTP/1.1\r\n\r\n"), []byte("HTTP/1.1 500\r\n\r\n"), dir)
+	RemovePartial()
+	entries, _ := os.ReadDir(dir)
+
+	testutils.AssertLen(t, entries, 1)
+	testutils.AssertEquals(t, entries[0].Name(), fname)
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type Hit struct {
+	Mutation string `json:"mutation"`
+	Mutable  string `json:"mutable"`
+	Tag      string `json:"tag,omitempty"`
+	Method   string `json:"method"`
+	Url      string `json:"url"`
+	Code     int    `json:"code"`
+	Length   int64  `json:"length"`
+	Report   string `json:"report"`
+}
+
+type Sarif struct {
+	mu   sync.Mutex
+	hits []Hit
+}
+
+func NewSarif() *Sarif {
+	return &Sarif{}
+}
+
+func (s *Sarif) Add(hit Hit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits = append(s.hits, hit)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+func (s *Sarif) document() sarifLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := map[string]bool{}
+	results := []sarifResult{}
+	for _, h := range s.hits {
+		rules[h.Mutation] = true
+		msg := fmt.Sprintf("%v @ %v: %v %v responded with code %v (length %v)", h.Mutation, h.Mutable, h.Method, h.Url, h.Code, h.Length)
+		if h.Report != "" {
+			msg += ", see " + h.Report
+		}
+		results = append(results, sarifResult{
+			RuleId:  h.Mutation,
+			Level:   "warning",
+			Message: sarifMessage{msg},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{sarifArtifactLocation{h.Url}},
+				LogicalLocations: []sarifLogicalLocation{{h.Mutable, "parameter"}},
+			}},
+		})
+	}
+
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	sRules := []sarifRule{}
+	for _, id := range ids {
+		sRules = append(sRules, sarifRule{id, sarifMessage{id + " mutation caused a reportable response"}})
+	}
+
+	driver := sarifDriver{"haze", "https://github.com/kamil-s-solecki/haze", sRules}
+	return sarifLog{"https://json.schemastore.org/sarif-2.1.0.json", "2.1.0", []sarifRun{{sarifTool{driver}, results}}}
+}
+
+func (s *Sarif) Write(path string) error {
+	bs, err := json.MarshalIndent(s.document(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, bs)
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"encoding/json"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSarifHasRequiredFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "haze.sarif")
+	sarif := NewSarif()
+	sarif.Add(Hit{"SingleQuotes", "Parameter", "sqli", "GET", "http://localhost/?id=1'", 500, 21, "1.md"})
+	sarif.Add(Hit{"SingleQuotes", "Path", "sqli", "GET", "http://localhost/a'", 500, 21, "2.md"})
+	sarif.Add(Hit{"Nullbyte", "Cookie", "nullbyte", "POST", "http://localhost/", 502, 0, "3.md"})
+
+	err := sarif.Write(path)
+
+	testutils.AssertTrue(t, err == nil)
+	bs, _ := os.ReadFile(path)
+	var doc map[string]interface{}
+	testutils.AssertTrue(t, json.Unmarshal(bs, &doc) == nil)
+	testutils.AssertEquals(t, doc["version"].(string), "2.1.0")
+
+	runs := doc["runs"].([]interface{})
+	testutils.AssertLen(t, runs, 1)
+	run := runs[0].(map[string]interface{})
+	driver := run["tool"].(map[string]interface{})["driver"].(map[string]interface{})
+	testutils.AssertEquals(t, driver["name"].(string), "haze")
+	testutils.AssertLen(t, driver["rules"].([]interface{}), 2)
+
+	results := run["results"].([]interface{})
+	testutils.AssertLen(t, results, 3)
+	for _, r := range results {
+		result := r.(map[string]interface{})
+		testutils.AssertTrue(t, result["ruleId"].(string) != "")
+		testutils.AssertTrue(t, result["message"].(map[string]interface{})["text"].(string) != "")
+		location := result["locations"].([]interface{})[0].(map[string]interface{})
+		uri := location["physicalLocation"].(map[string]interface{})["artifactLocation"].(map[string]interface{})["uri"]
+		testutils.AssertTrue(t, uri.(string) != "")
+	}
+}
+
+func TestEmptySarifHasNoResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "haze.sarif")
+
+	NewSarif().Write(path)
+
+	bs, _ := os.ReadFile(path)
+	var doc sarifLog
+	json.Unmarshal(bs, &doc)
+	testutils.AssertLen(t, doc.Runs, 1)
+	testutils.AssertLen(t, doc.Runs[0].Results, 0)
+}
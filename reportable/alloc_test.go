@@ -0,0 +1,25 @@
+//go:build !race
+
+package reportable
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+// TestMatchingDoesNotAllocate is left out of -race runs, whose instrumentation allocates
+func TestMatchingDoesNotAllocate(t *testing.T) {
+	ms, fs := allMatchersAndFilters()
+	res := syntheticResponses(20)[13]
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, m := range ms {
+			m(res)
+		}
+		for _, f := range fs {
+			f(res)
+		}
+	})
+
+	testutils.AssertEquals(t, allocs, float64(0))
+}
@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"github.com/kamil-s-solecki/haze/cliargs"
 	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/utils"
+	"math"
+	"mime"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Matcher func(http.Response) bool
@@ -28,9 +33,115 @@ func MatchLengths(lens string) Matcher {
 	}
 }
 
+// MatchLengthWithin matches lengths within ±percent of the baseline, bounds included.
+func MatchLengthWithin(baseline int, percent float64) Matcher {
+	tolerance := float64(baseline) * percent / 100
+	return func(res http.Response) bool {
+		return math.Abs(float64(res.Length)-float64(baseline)) <= tolerance
+	}
+}
+
+func MatchWords(words string) Matcher {
+	ranges := parseRanges(words)
+	return func(res http.Response) bool {
+		return isValueInRanges(ranges, res.Words())
+	}
+}
+
+func MatchLines(lines string) Matcher {
+	ranges := parseRanges(lines)
+	return func(res http.Response) bool {
+		return isValueInRanges(ranges, res.Lines())
+	}
+}
+
+// MatchTime matches the responses taking more (op ">") or less (op "<") than the threshold
+func MatchTime(threshold time.Duration, op string) Matcher {
+	return func(res http.Response) bool {
+		if op == "<" {
+			return res.Duration < threshold
+		}
+		return res.Duration > threshold
+	}
+}
+
+// matchTimeArg reads the -mt value, e.g. >5000, <100 or 1000-3000 milliseconds, bounds included
+func matchTimeArg(val string) Matcher {
+	if op := val[:1]; op == ">" || op == "<" {
+		ms, _ := strconv.Atoi(val[1:])
+		return MatchTime(time.Duration(ms)*time.Millisecond, op)
+	}
+	ran := parseRange(val)
+	return MatchAll(MatchTime(time.Duration(ran.From)*time.Millisecond-1, ">"), MatchTime(time.Duration(ran.To+1)*time.Millisecond, "<"))
+}
+
 func MatchString(str string) Matcher {
+	bs := []byte(str)
+	return func(res http.Response) bool {
+		return bytes.Contains(res.Raw, bs)
+	}
+}
+
+// MatchEmptyBody looks at the body read from the wire, not at Content-Length. With trimSpace
+// a body of whitespace only counts as empty.
+func MatchEmptyBody(trimSpace bool) Matcher {
+	return func(res http.Response) bool {
+		return isBodyEmpty(res, trimSpace)
+	}
+}
+
+func MatchNonEmptyBody(trimSpace bool) Matcher {
+	return func(res http.Response) bool {
+		return !isBodyEmpty(res, trimSpace)
+	}
+}
+
+func isBodyEmpty(res http.Response, trimSpace bool) bool {
+	body := res.Body()
+	if trimSpace {
+		body = bytes.TrimSpace(body)
+	}
+	return len(body) == 0
+}
+
+func MatchReflection(payload string, encoded bool) Matcher {
+	variants := [][]byte{[]byte(payload)}
+	if encoded {
+		for _, enc := range http.EncodedForms(payload) {
+			variants = append(variants, []byte(enc))
+		}
+	}
+	return func(res http.Response) bool {
+		if len(payload) == 0 {
+			return false
+		}
+		body := res.Body()
+		for _, v := range variants {
+			if bytes.Contains(body, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchContentType compares the media type of the response, ignoring parameters like charset.
+func MatchContentType(types ...string) Matcher {
 	return func(res http.Response) bool {
-		return bytes.Contains(res.Raw, []byte(str))
+		return hasMediaType(res, types)
+	}
+}
+
+// MatchHeader matches responses with the header, looked up case-insensitively. A non-empty substr
+// has to be contained in one of the header's values.
+func MatchHeader(name, substr string) Matcher {
+	return func(res http.Response) bool {
+		for _, val := range res.HeaderValues(name) {
+			if strings.Contains(val, substr) {
+				return true
+			}
+		}
+		return false
 	}
 }
 
@@ -49,8 +160,45 @@ func FilterLengths(lens string) Filter {
 }
 
 func FilterString(str string) Filter {
+	bs := []byte(str)
+	return func(res http.Response) bool {
+		return !bytes.Contains(res.Raw, bs)
+	}
+}
+
+func FilterContentType(types ...string) Filter {
 	return func(res http.Response) bool {
-		return !bytes.Contains(res.Raw, []byte(str))
+		return !hasMediaType(res, types)
+	}
+}
+
+func hasMediaType(res http.Response, types []string) bool {
+	ct := res.Header("Content-Type")
+	if ct == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(ct, ";")
+	}
+	for _, t := range types {
+		if strings.EqualFold(strings.TrimSpace(mediaType), strings.TrimSpace(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterBaseline drops responses statistically identical to the baseline: the same code,
+// with the length and word count within ±percent of the baseline ones.
+func FilterBaseline(baseline http.Response, percent float64) Filter {
+	sameLength := MatchLengthWithin(int(baseline.Length), percent)
+	words := baseline.Words()
+	wordTolerance := float64(words) * percent / 100
+	return func(res http.Response) bool {
+		identical := res.Code == baseline.Code && sameLength(res) &&
+			math.Abs(float64(res.Words()-words)) <= wordTolerance
+		return !identical
 	}
 }
 
@@ -84,15 +232,63 @@ func parseRange(val string) Range {
 	return ran
 }
 
+// MatchAll matches responses matched by each of the matchers
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(res http.Response) bool {
+		for _, matcher := range matchers {
+			if !matcher(res) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func reflection(args cliargs.Args) bool {
+	return args.MatchReflected || args.MatchEncoded
+}
+
+// WithReflection adds the -mr matcher of the payload of a mutant to the matchers of FromArgs
+func WithReflection(matchers []Matcher, args cliargs.Args, payload string) []Matcher {
+	if !reflection(args) {
+		return matchers
+	}
+	return append(append([]Matcher{}, matchers...), MatchReflection(payload, args.MatchEncoded))
+}
+
 func FromArgs(args cliargs.Args) ([]Matcher, []Filter) {
 	matchers := []Matcher{}
-	if args.MatchLengths != "" {
+	// an explicit -mc narrows -ml instead of reporting either of them
+	codesAndLengths := args.MatchLengths != "" && args.MatchCodesGiven
+	if codesAndLengths {
+		matchers = append(matchers, MatchAll(MatchCodes(args.MatchCodes), MatchLengths(args.MatchLengths)))
+	} else if args.MatchLengths != "" {
 		matchers = append(matchers, MatchLengths(args.MatchLengths))
 	}
+	if args.MatchWords != "" {
+		matchers = append(matchers, MatchWords(args.MatchWords))
+	}
+	if args.MatchLines != "" {
+		matchers = append(matchers, MatchLines(args.MatchLines))
+	}
 	if args.MatchString != "" {
 		matchers = append(matchers, MatchString(args.MatchString))
 	}
-	if !(len(matchers) > 0 && args.MatchCodes == "500-599") {
+	if args.MatchTime != "" {
+		matchers = append(matchers, matchTimeArg(args.MatchTime))
+	}
+	for _, h := range args.MatchHeaders {
+		name, substr, _ := strings.Cut(h, ":")
+		matchers = append(matchers, MatchHeader(strings.TrimSpace(name), strings.TrimSpace(substr)))
+	}
+	if args.MatchErrors || args.ErrorSignatures != "" {
+		extra := []*regexp.Regexp{}
+		if args.ErrorSignatures != "" {
+			extra, _ = utils.ReadRegexes(args.ErrorSignatures)
+		}
+		matchers = append(matchers, MatchErrorSignatures(extra...))
+	}
+	if !codesAndLengths && !((len(matchers) > 0 || reflection(args)) && !args.MatchCodesGiven) {
 		matchers = append(matchers, MatchCodes(args.MatchCodes))
 	}
 
@@ -106,6 +302,13 @@ func FromArgs(args cliargs.Args) ([]Matcher, []Filter) {
 	if args.FilterString != "" {
 		filters = append(filters, FilterString(args.FilterString))
 	}
+	// -mct keeps only its content types, whatever else matched
+	if args.MatchTypes != "" {
+		filters = append(filters, Filter(MatchContentType(strings.Split(args.MatchTypes, ",")...)))
+	}
+	if args.FilterTypes != "" {
+		filters = append(filters, FilterContentType(strings.Split(args.FilterTypes, ",")...))
+	}
 	return matchers, filters
 }
 
@@ -1,10 +1,13 @@
 package reportable
 
 import (
+	"fmt"
 	"github.com/kamil-s-solecki/haze/cliargs"
 	"github.com/kamil-s-solecki/haze/http"
 	"github.com/kamil-s-solecki/haze/testutils"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestShouldNotReport200(t *testing.T) {
@@ -51,6 +54,56 @@ func TestShouldReportLengths(t *testing.T) {
 	}
 }
 
+func TestShouldMatchLengthWithinTolerance(t *testing.T) {
+	cases := []struct {
+		baseline int
+		percent  float64
+		length   int64
+		want     bool
+	}{
+		{1000, 10, 1000, true},
+		{1000, 10, 900, true},
+		{1000, 10, 1100, true},
+		{1000, 10, 899, false},
+		{1000, 10, 1101, false},
+		{1000, 2.5, 1025, true},
+		{1000, 2.5, 1026, false},
+		{0, 50, 0, true},
+		{0, 50, 1, false},
+	}
+
+	for _, c := range cases {
+		res := http.Response{Code: 200, Length: c.length}
+
+		testutils.AssertEquals(t, MatchLengthWithin(c.baseline, c.percent)(res), c.want)
+	}
+}
+
+func TestShouldFilterResponsesIdenticalToBaseline(t *testing.T) {
+	response := func(code int, body string) http.Response {
+		raw := fmt.Sprintf("HTTP/1.1 %v X\r\n\r\n%v", code, body)
+		return http.Response{Code: code, Length: int64(len(body)), Raw: []byte(raw)}
+	}
+	baseline := response(500, strings.Repeat("error at 12:00:01 ", 20))
+	filter := FilterBaseline(baseline, 5)
+
+	cases := []struct {
+		res      http.Response
+		reported bool
+	}{
+		{baseline, false},
+		{response(500, strings.Repeat("error at 12:00:02 ", 20)), false},
+		{response(500, strings.Repeat("error at 12:00:02 ", 20)+"nonce=abc"), false},
+		{response(500, strings.Repeat("error at 12:00:01 ", 10)), true},
+		{response(502, strings.Repeat("error at 12:00:01 ", 20)), true},
+		{response(500, strings.Repeat("error at 12:00:01 ", 19)+strings.Repeat("x ", 9)), true},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, IsReportable(c.res, []Matcher{MatchCodes("500-599")}, []Filter{filter}), c.reported)
+	}
+}
+
 func TestShouldReport500When200IsFiltered(t *testing.T) {
 	res := http.Response{Code: 500}
 
@@ -102,14 +155,57 @@ func TestShouldConstructFromArgsWithCodesOnly(t *testing.T) {
 }
 
 func TestShouldConstructFromArgsWithCodesAndLens(t *testing.T) {
-	args := cliargs.Args{MatchCodes: "500,501-502", MatchLengths: "100-200"}
+	args := cliargs.Args{MatchCodes: "500,501-502", MatchCodesGiven: true, MatchLengths: "100-200"}
 
 	ms, fs := FromArgs(args)
 
-	testutils.AssertLen(t, ms, 2)
+	testutils.AssertLen(t, ms, 1)
 	testutils.AssertLen(t, fs, 0)
-	testutils.AssertTrue(t, IsReportable(http.Response{Code: 500}, ms, fs))
+	testutils.AssertTrue(t, IsReportable(http.Response{Code: 500, Length: 150}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 500}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 200, Length: 150}, ms, fs))
+}
+
+func TestShouldConstructFromArgsWithLensAndDefaultCodes(t *testing.T) {
+	args := cliargs.Args{MatchCodes: "500-599", MatchLengths: "100-200"}
+
+	ms, fs := FromArgs(args)
+
+	testutils.AssertLen(t, ms, 1)
 	testutils.AssertTrue(t, IsReportable(http.Response{Code: 200, Length: 150}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 500}, ms, fs))
+}
+
+func TestShouldConstructFromArgsWithLensAndExplicitDefaultCodes(t *testing.T) {
+	args := cliargs.Args{MatchCodes: "500-599", MatchCodesGiven: true, MatchLengths: "100-200"}
+
+	ms, fs := FromArgs(args)
+
+	testutils.AssertLen(t, ms, 1)
+	testutils.AssertTrue(t, IsReportable(http.Response{Code: 500, Length: 150}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 200, Length: 150}, ms, fs))
+}
+
+func TestShouldNotReportCodeOnlyMatchWithLensAndOtherMatchers(t *testing.T) {
+	args := cliargs.Args{MatchCodes: "500", MatchCodesGiven: true, MatchLengths: "100-200", MatchString: "needle"}
+
+	ms, fs := FromArgs(args)
+
+	testutils.AssertLen(t, ms, 2)
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 500}, ms, fs))
+	testutils.AssertTrue(t, IsReportable(http.Response{Code: 200, Raw: []byte("needle")}, ms, fs))
+}
+
+func TestContentTypesFromArgsNarrowTheOtherMatchers(t *testing.T) {
+	args := cliargs.Args{MatchCodes: "500-599", MatchTypes: "application/json"}
+	jsonType := map[string][]string{"Content-Type": {"application/json; charset=utf-8"}}
+	htmlType := map[string][]string{"Content-Type": {"text/html"}}
+
+	ms, fs := FromArgs(args)
+
+	testutils.AssertTrue(t, IsReportable(http.Response{Code: 500, Headers: jsonType}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 500, Headers: htmlType}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 200, Headers: jsonType}, ms, fs))
 }
 
 func TestShouldConstructFromArgsWithFilters(t *testing.T) {
@@ -155,3 +251,209 @@ func TestShouldReportWhenStringDoesNotFilter(t *testing.T) {
 
 	testutils.AssertTrue(t, got)
 }
+
+func TestShouldMatchContentType(t *testing.T) {
+	cases := []struct {
+		ct   string
+		want bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"Application/JSON;charset=UTF-8", true},
+		{"text/xml", true},
+		{"text/html; charset=utf-8", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		res := http.Response{Code: 200, Headers: map[string][]string{}}
+		if c.ct != "" {
+			res.Headers["Content-Type"] = []string{c.ct}
+		}
+
+		testutils.AssertEquals(t, IsReportable(res, []Matcher{MatchContentType("application/json", "text/xml")}, []Filter{}), c.want)
+		testutils.AssertEquals(t, IsReportable(res, []Matcher{}, []Filter{FilterContentType("application/json", "text/xml")}), !c.want)
+	}
+}
+
+func TestShouldMatchEmptyBody(t *testing.T) {
+	cases := []struct {
+		raw       string
+		trimSpace bool
+		empty     bool
+	}{
+		{"HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n", false, true},
+		{"HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\n", false, true},
+		{"HTTP/1.1 200 OK\r\n\r\n \r\n\t", false, false},
+		{"HTTP/1.1 200 OK\r\n\r\n \r\n\t", true, true},
+		{"HTTP/1.1 200 OK\r\n\r\n{\"ok\": true}", true, false},
+	}
+
+	for _, c := range cases {
+		res := http.Response{Code: 200, Raw: []byte(c.raw)}
+
+		testutils.AssertEquals(t, MatchEmptyBody(c.trimSpace)(res), c.empty)
+		testutils.AssertEquals(t, MatchNonEmptyBody(c.trimSpace)(res), !c.empty)
+	}
+}
+
+func TestShouldReportWords(t *testing.T) {
+	res := http.Response{Raw: []byte("HTTP/1.1 200 OK\r\n\r\nfoo bar baz")}
+
+	testutils.AssertTrue(t, IsReportable(res, []Matcher{MatchWords("3")}, []Filter{}))
+	testutils.AssertTrue(t, IsReportable(res, []Matcher{MatchWords("1,2-4")}, []Filter{}))
+	testutils.AssertFalse(t, IsReportable(res, []Matcher{MatchWords("4-10")}, []Filter{}))
+}
+
+func TestShouldReportLines(t *testing.T) {
+	res := http.Response{Raw: []byte("HTTP/1.1 200 OK\r\n\r\nfoo\nbar\n")}
+
+	testutils.AssertTrue(t, IsReportable(res, []Matcher{MatchLines("2")}, []Filter{}))
+	testutils.AssertFalse(t, IsReportable(res, []Matcher{MatchLines("3")}, []Filter{}))
+}
+
+func TestShouldConstructFromArgsWithWordsAndLines(t *testing.T) {
+	args := cliargs.Args{MatchCodes: "500-599", MatchWords: "3", MatchLines: "1"}
+
+	ms, fs := FromArgs(args)
+
+	testutils.AssertLen(t, ms, 2)
+	testutils.AssertLen(t, fs, 0)
+	testutils.AssertTrue(t, IsReportable(http.Response{Code: 200, Raw: []byte("HTTP/1.1 200 OK\r\n\r\nfoo bar baz")}, ms, fs))
+}
+
+func TestShouldReportSlowResponses(t *testing.T) {
+	slow := http.Response{Code: 200, Duration: 6 * time.Second}
+	fast := http.Response{Code: 200, Duration: 80 * time.Millisecond}
+
+	testutils.AssertTrue(t, IsReportable(slow, []Matcher{MatchTime(5*time.Second, ">")}, []Filter{}))
+	testutils.AssertFalse(t, IsReportable(fast, []Matcher{MatchTime(5*time.Second, ">")}, []Filter{}))
+	testutils.AssertTrue(t, IsReportable(fast, []Matcher{MatchTime(100*time.Millisecond, "<")}, []Filter{}))
+	testutils.AssertFalse(t, IsReportable(slow, []Matcher{MatchTime(100*time.Millisecond, "<")}, []Filter{}))
+}
+
+func TestShouldConstructFromArgsWithTime(t *testing.T) {
+	cases := []struct {
+		matchTime string
+		duration  time.Duration
+		reported  bool
+	}{
+		{">5000", 5001 * time.Millisecond, true},
+		{">5000", 5000 * time.Millisecond, false},
+		{"<100", 99 * time.Millisecond, true},
+		{"<100", 2 * time.Second, false},
+		{"1000-3000", 1000 * time.Millisecond, true},
+		{"1000-3000", 3000*time.Millisecond + 500*time.Microsecond, true},
+		{"1000-3000", 999 * time.Millisecond, false},
+		{"1000-3000", 3001 * time.Millisecond, false},
+	}
+
+	for _, c := range cases {
+		ms, fs := FromArgs(cliargs.Args{MatchCodes: "500-599", MatchTime: c.matchTime})
+
+		testutils.AssertLen(t, ms, 1)
+		testutils.AssertEquals(t, IsReportable(http.Response{Code: 200, Duration: c.duration}, ms, fs), c.reported)
+	}
+}
+
+func TestReflectionFromArgs(t *testing.T) {
+	args := cliargs.Args{MatchCodes: "500-599", MatchEncoded: true}
+	res := http.Response{Code: 200, Raw: []byte("HTTP/1.1 200 OK\r\n\r\nhi &lt;x&gt;")}
+
+	ms, fs := FromArgs(args)
+
+	testutils.AssertLen(t, ms, 0)
+	testutils.AssertTrue(t, IsReportable(res, WithReflection(ms, args, "<x>"), fs))
+	testutils.AssertFalse(t, IsReportable(res, WithReflection(ms, args, "<y>"), fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 500}, WithReflection(ms, args, "<y>"), fs))
+	testutils.AssertLen(t, WithReflection(ms, cliargs.Args{}, "<x>"), 0)
+}
+
+func TestShouldReportReflection(t *testing.T) {
+	res := http.Response{Raw: []byte("HTTP/1.1 200 OK\r\n\r\nHello foo&#39;bar")}
+
+	testutils.AssertFalse(t, IsReportable(res, []Matcher{MatchReflection("foo'bar", false)}, []Filter{}))
+	testutils.AssertTrue(t, IsReportable(res, []Matcher{MatchReflection("foo'bar", true)}, []Filter{}))
+}
+
+func allMatchersAndFilters() ([]Matcher, []Filter) {
+	args := cliargs.Args{MatchCodes: "500-599", MatchLengths: "100-200", MatchWords: "3,10-20", MatchLines: "5",
+		MatchString: "needle", MatchErrors: true, FilterCodes: "404", FilterLengths: "0", FilterString: "Not Found"}
+	ms, fs := FromArgs(args)
+	return append(ms, MatchReflection("<x'y>", true)), fs
+}
+
+func syntheticResponses(n int) []http.Response {
+	result := []http.Response{}
+	for i := 0; i < n; i++ {
+		body := strings.Repeat(fmt.Sprintf("line %v with some words in it\n", i), i%50)
+		raw := []byte("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n" + body)
+		result = append(result, http.Response{Code: 200 + i%400, Length: int64(len(body)), Raw: raw})
+	}
+	return result
+}
+
+func BenchmarkIsReportable(b *testing.B) {
+	ms, fs := allMatchersAndFilters()
+	responses := syntheticResponses(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res := responses[i%len(responses)]
+		for _, m := range ms {
+			m(res)
+		}
+		for _, f := range fs {
+			f(res)
+		}
+	}
+}
+
+// the sent responses come cached, the body matchers then slice Raw instead of scanning it each
+func BenchmarkBodyMatchers(b *testing.B) {
+	ms := []Matcher{MatchNonEmptyBody(true), MatchLines("5"), MatchReflection("<x'y>", false)}
+	body := strings.Repeat("line with some words in it\n", 2000)
+	res := http.Response{Code: 200, Raw: []byte("HTTP/1.1 200 OK\r\n" + strings.Repeat("X-Padding: abc\r\n", 200) + "\r\n" + body)}
+
+	for _, c := range []struct {
+		name string
+		res  http.Response
+	}{{"scanned", res}, {"cached", res.Cached()}} {
+		res := c.res
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, m := range ms {
+					m(res)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchHeaderPresence(t *testing.T) {
+	res := http.Response{Code: 200, Headers: map[string][]string{"X-Debug": {""}}}
+
+	testutils.AssertTrue(t, IsReportable(res, []Matcher{MatchHeader("x-debug", "")}, []Filter{}))
+	testutils.AssertFalse(t, IsReportable(res, []Matcher{MatchHeader("X-Trace", "")}, []Filter{}))
+}
+
+func TestMatchHeaderValueSubstring(t *testing.T) {
+	res := http.Response{Code: 302, Headers: map[string][]string{"Location": {"https://example.com/admin/login"}}}
+
+	testutils.AssertTrue(t, IsReportable(res, []Matcher{MatchHeader("Location", "/admin")}, []Filter{}))
+	testutils.AssertFalse(t, IsReportable(res, []Matcher{MatchHeader("Location", "/home")}, []Filter{}))
+}
+
+func TestShouldConstructFromArgsWithHeaders(t *testing.T) {
+	args := cliargs.Args{MatchCodes: "500-599", MatchHeaders: cliargs.StringArrayArg{"Location: /admin", "X-Debug"}}
+
+	ms, fs := FromArgs(args)
+
+	testutils.AssertLen(t, ms, 2)
+	testutils.AssertTrue(t, IsReportable(http.Response{Code: 302, Headers: map[string][]string{"Location": {"/admin"}}}, ms, fs))
+	testutils.AssertTrue(t, IsReportable(http.Response{Code: 200, Headers: map[string][]string{"X-Debug": {"1"}}}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 302, Headers: map[string][]string{"Location": {"/"}}}, ms, fs))
+	testutils.AssertFalse(t, IsReportable(http.Response{Code: 500}, ms, fs))
+}
@@ -0,0 +1,50 @@
+package reportable
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+	"regexp"
+)
+
+var ErrorSignatures = []*regexp.Regexp{
+	// MySQL
+	regexp.MustCompile(`You have an error in your SQL syntax`),
+	regexp.MustCompile(`Warning: mysqli?_[a-z_]+\(`),
+	regexp.MustCompile(`MySqlException`),
+	// PostgreSQL
+	regexp.MustCompile(`ERROR:\s+(syntax error at or near|unterminated quoted string)`),
+	regexp.MustCompile(`pg_query\(\)`),
+	regexp.MustCompile(`PSQLException`),
+	// MSSQL, Oracle, SQLite
+	regexp.MustCompile(`Unclosed quotation mark after the character string`),
+	regexp.MustCompile(`ORA-[0-9]{5}`),
+	regexp.MustCompile(`SQLITE_ERROR|sqlite3\.OperationalError`),
+	// Java
+	regexp.MustCompile(`at [a-zA-Z0-9_.$]+\([A-Za-z0-9_]+\.java:[0-9]+\)`),
+	regexp.MustCompile(`java\.lang\.[A-Za-z]+(Exception|Error)`),
+	// Python
+	regexp.MustCompile(`Traceback \(most recent call last\):`),
+	regexp.MustCompile(`You're seeing this error because you have <code>DEBUG = True</code>`),
+	// Go
+	regexp.MustCompile(`goroutine [0-9]+ \[running\]:`),
+	regexp.MustCompile(`panic: runtime error`),
+	// PHP
+	regexp.MustCompile(`(Warning|Fatal error|Parse error|Notice)(</b>)?: .* on line (<b>)?[0-9]+`),
+	// .NET
+	regexp.MustCompile(`Server Error in '[^']*' Application`),
+	regexp.MustCompile(`System\.[A-Za-z.]+Exception`),
+	// Node.js
+	regexp.MustCompile(`at [^\n]+ \([^)]+\.js:[0-9]+:[0-9]+\)`),
+}
+
+func MatchErrorSignatures(extra ...*regexp.Regexp) Matcher {
+	signatures := append(append([]*regexp.Regexp{}, ErrorSignatures...), extra...)
+	return func(res http.Response) bool {
+		body := res.Body()
+		for _, s := range signatures {
+			if s.Match(body) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,40 @@
+package reportable
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"regexp"
+	"testing"
+)
+
+func response(body string) http.Response {
+	return http.Response{Code: 200, Raw: []byte("HTTP/1.1 200 OK\r\n\r\n" + body)}
+}
+
+func TestShouldMatchErrorSignatures(t *testing.T) {
+	bodies := []string{
+		"<p>You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version</p>",
+		"ERROR:  syntax error at or near \"'\"",
+		"Traceback (most recent call last):\n  File \"app.py\", line 3, in <module>",
+		"java.lang.NullPointerException\n\tat com.example.Foo.bar(Foo.java:42)",
+		"panic: runtime error: index out of range\n\ngoroutine 1 [running]:",
+		"<b>Warning</b>:  include(foo'): failed to open stream in <b>/var/www/index.php</b> on line <b>3</b>",
+	}
+
+	for _, b := range bodies {
+		testutils.AssertTrue(t, MatchErrorSignatures()(response(b)))
+	}
+}
+
+func TestShouldNotMatchCleanBody(t *testing.T) {
+	res := response("<html><body>Welcome back, user!</body></html>")
+
+	testutils.AssertFalse(t, MatchErrorSignatures()(res))
+}
+
+func TestShouldMatchCustomErrorSignature(t *testing.T) {
+	res := response("Oops: FooFrameworkError in handler")
+
+	testutils.AssertFalse(t, MatchErrorSignatures()(res))
+	testutils.AssertTrue(t, MatchErrorSignatures(regexp.MustCompile(`FooFramework[A-Za-z]*Error`))(res))
+}
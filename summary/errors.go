@@ -0,0 +1,38 @@
+package summary
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+const (
+	Timeout = "timeout"
+	Refused = "refused"
+	Reset   = "reset"
+	Tls     = "tls"
+	Other   = "other"
+)
+
+func ErrorClass(err error) string {
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var authErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return Timeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return Refused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return Reset
+	case errors.As(err, &recordErr), errors.As(err, &authErr), errors.As(err, &hostErr),
+		strings.Contains(err.Error(), "tls: "):
+		return Tls
+	}
+	return Other
+}
@@ -0,0 +1,60 @@
+package summary
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestErrorClass(t *testing.T) {
+	cases := []struct {
+		err   error
+		class string
+	}{
+		{fmt.Errorf("Get: %w", context.DeadlineExceeded), Timeout},
+		{&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, Timeout},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, Refused},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, Reset},
+		{fmt.Errorf("Get: %w", x509.UnknownAuthorityError{}), Tls},
+		{errors.New("remote error: tls: handshake failure"), Tls},
+		{errors.New("malformed HTTP response"), Other},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, ErrorClass(c.err), c.class)
+	}
+}
+
+func TestClassifyRealErrors(t *testing.T) {
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := "http://" + l.Addr().String()
+	l.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+	untrusted := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	untrusted.Config.ErrorLog = log.New(io.Discard, "", 0)
+	untrusted.StartTLS()
+	defer untrusted.Close()
+	client := &http.Client{Timeout: 10 * time.Millisecond}
+
+	_, refused := client.Get(closed)
+	_, timeout := client.Get(slow.URL)
+	_, tlsErr := http.Get(untrusted.URL)
+
+	testutils.AssertEquals(t, ErrorClass(refused), Refused)
+	testutils.AssertEquals(t, ErrorClass(timeout), Timeout)
+	testutils.AssertEquals(t, ErrorClass(tlsErr), Tls)
+}
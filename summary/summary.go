@@ -0,0 +1,54 @@
+package summary
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+	"sync"
+	"time"
+)
+
+type Summary struct {
+	mu           sync.Mutex
+	start        time.Time
+	Requests     int
+	Classes      map[int]int
+	Errors       int
+	ErrorClasses map[string]int
+	Reported     int
+	Tags         map[string]int
+	// Interrupted tells that the run was cut short, e.g. with Ctrl-C
+	Interrupted bool
+}
+
+func Start() *Summary {
+	return &Summary{start: time.Now(), Classes: map[int]int{}, ErrorClasses: map[string]int{}, Tags: map[string]int{}}
+}
+
+// Add counts the response to a mutant tagged with tag, which may be empty
+func (s *Summary) Add(res http.Response, err error, reported bool, tag string) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.Requests++
+	if err != nil {
+		s.Errors++
+		s.ErrorClasses[ErrorClass(err)]++
+		return
+	}
+	s.Classes[res.Class()]++
+	if reported {
+		s.Reported++
+		if tag != "" {
+			s.Tags[tag]++
+		}
+	}
+}
+
+func (s *Summary) Interrupt() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	s.Interrupted = true
+}
+
+func (s *Summary) Elapsed() time.Duration {
+	return time.Since(s.start)
+}
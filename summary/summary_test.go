@@ -0,0 +1,59 @@
+package summary
+
+import (
+	"errors"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"sync"
+	"testing"
+)
+
+func TestAggregateResults(t *testing.T) {
+	results := []struct {
+		res      http.Response
+		err      error
+		reported bool
+		tag      string
+	}{
+		{http.Response{Code: 200}, nil, false, "sqli"},
+		{http.Response{Code: 204}, nil, false, ""},
+		{http.Response{Code: 302}, nil, false, ""},
+		{http.Response{Code: 404}, nil, false, ""},
+		{http.Response{Code: 500}, nil, true, "sqli"},
+		{http.Response{Code: 503}, nil, true, ""},
+		{http.Response{}, errors.New("connection refused"), false, "ssti"},
+	}
+	s := Start()
+
+	for _, r := range results {
+		s.Add(r.res, r.err, r.reported, r.tag)
+	}
+
+	testutils.AssertEquals(t, s.Requests, 7)
+	testutils.AssertMapEquals(t, s.Classes, map[int]int{2: 2, 3: 1, 4: 1, 5: 2})
+	testutils.AssertEquals(t, s.Errors, 1)
+	testutils.AssertEquals(t, s.ErrorClasses[Other], 1)
+	testutils.AssertEquals(t, len(s.ErrorClasses), 1)
+	testutils.AssertEquals(t, s.Reported, 2)
+	testutils.AssertEquals(t, s.Tags["sqli"], 1)
+	testutils.AssertEquals(t, len(s.Tags), 1)
+}
+
+func TestAggregateConcurrently(t *testing.T) {
+	s := Start()
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Add(http.Response{Code: 500}, nil, true, "sqli")
+		}()
+	}
+	wg.Wait()
+
+	testutils.AssertEquals(t, s.Requests, 100)
+	testutils.AssertEquals(t, s.Classes[5], 100)
+	testutils.AssertEquals(t, s.Reported, 100)
+	testutils.AssertEquals(t, s.Tags["sqli"], 100)
+}
@@ -0,0 +1,87 @@
+package template
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var placeholder = regexp.MustCompile(`\\?\{\{([A-Za-z_][A-Za-z0-9_]*)\}\}`)
+
+// Render substitutes {{NAME}} placeholders from vars, falling back to the environment with fromEnv.
+// Unknown placeholders are left as they are and \{{NAME}} stands for a literal {{NAME}}.
+func Render(raw []byte, vars map[string]string, fromEnv bool) []byte {
+	return placeholder.ReplaceAllFunc(raw, func(m []byte) []byte {
+		if m[0] == '\\' {
+			return m[1:]
+		}
+		name := string(m[2 : len(m)-2])
+		if val, ok := vars[name]; ok {
+			return []byte(val)
+		}
+		if val, ok := os.LookupEnv(name); ok && fromEnv {
+			return []byte(val)
+		}
+		return m
+	})
+}
+
+func ParseVars(list []string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, v := range list {
+		name, val, found := strings.Cut(v, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("Invalid variable: '%v'. Example correct value: 'TOKEN=abc'", v)
+		}
+		vars[name] = val
+	}
+	return vars, nil
+}
+
+// ReadDataset reads a CSV file whose header row names the placeholders. Each other row is one set of values.
+func ReadDataset(path string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("The dataset %v has no header row", path)
+	}
+
+	rows := []map[string]string{}
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, name := range records[0] {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Iterations merges vars into every dataset row, the row taking precedence. Without a dataset there is a single iteration.
+func Iterations(vars map[string]string, dataset []map[string]string) []map[string]string {
+	if dataset == nil {
+		return []map[string]string{vars}
+	}
+	result := []map[string]string{}
+	for _, row := range dataset {
+		merged := map[string]string{}
+		for k, v := range vars {
+			merged[k] = v
+		}
+		for k, v := range row {
+			merged[k] = v
+		}
+		result = append(result, merged)
+	}
+	return result
+}
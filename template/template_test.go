@@ -0,0 +1,67 @@
+package template
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Setenv("HAZE_TEST_SESSION", "s3cr3t")
+	vars := map[string]string{"FUZZ": "'", "TOKEN": "abc"}
+
+	cases := []struct {
+		raw, want string
+	}{
+		{"GET /?q={{FUZZ}}&t={{TOKEN}} HTTP/1.1", "GET /?q='&t=abc HTTP/1.1"},
+		{"Cookie: sid={{HAZE_TEST_SESSION}}", "Cookie: sid=s3cr3t"},
+		{"{{UNKNOWN}} {{ FUZZ }} {FUZZ}", "{{UNKNOWN}} {{ FUZZ }} {FUZZ}"},
+		{`{"tpl": "\{{FUZZ}}", "v": "{{FUZZ}}"}`, `{"tpl": "{{FUZZ}}", "v": "'"}`},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, string(Render([]byte(c.raw), vars, true)), c.want)
+	}
+}
+
+func TestEnvironmentIsNotUsedUnlessAsked(t *testing.T) {
+	t.Setenv("HAZE_TEST_USER", "alice")
+
+	got := Render([]byte("GET /?name={{HAZE_TEST_USER}}&q={{FUZZ}} HTTP/1.1"), map[string]string{"FUZZ": "'"}, false)
+
+	testutils.AssertEquals(t, string(got), "GET /?name={{HAZE_TEST_USER}}&q=' HTTP/1.1")
+}
+
+func TestParseVars(t *testing.T) {
+	vars, err := ParseVars([]string{"TOKEN=abc", "EMPTY=", "EQ=a=b"})
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertMapEquals(t, vars, map[string]string{"TOKEN": "abc", "EMPTY": "", "EQ": "a=b"})
+
+	_, err = ParseVars([]string{"TOKEN"})
+	testutils.AssertTrue(t, err != nil)
+}
+
+func TestIterateDataset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	os.WriteFile(path, []byte("USER,ID\nalice,1\nbob,2\n"), 0644)
+	dataset, err := ReadDataset(path)
+	testutils.AssertTrue(t, err == nil)
+
+	got := []string{}
+	for _, vars := range Iterations(map[string]string{"ID": "0", "HOST": "example.com"}, dataset) {
+		got = append(got, string(Render([]byte("/{{USER}}/{{ID}}@{{HOST}}"), vars, false)))
+	}
+
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertEquals(t, got[0], "/alice/1@example.com")
+	testutils.AssertEquals(t, got[1], "/bob/2@example.com")
+}
+
+func TestSingleIterationWithoutDataset(t *testing.T) {
+	iterations := Iterations(map[string]string{"FUZZ": "x"}, nil)
+
+	testutils.AssertLen(t, iterations, 1)
+	testutils.AssertEquals(t, iterations[0]["FUZZ"], "x")
+}
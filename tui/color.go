@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	reset  = "\033[0m"
+	red    = "\033[31m"
+	green  = "\033[32m"
+	yellow = "\033[33m"
+)
+
+var escapeSeq = regexp.MustCompile("\033\\[[0-9;]*m")
+
+func classColor(class int) string {
+	switch class {
+	case 2:
+		return green
+	case 3:
+		return yellow
+	case 5:
+		return red
+	default:
+		return ""
+	}
+}
+
+func (t *Tui) colorize(val string, color string) string {
+	if !t.color || color == "" {
+		return val
+	}
+	return color + val + reset
+}
+
+func (t *Tui) code(res http.Response) string {
+	return t.colorize(strconv.Itoa(res.Code), classColor(res.Class()))
+}
+
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(escapeSeq.ReplaceAllString(s, ""))
+}
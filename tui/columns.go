@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"github.com/kamil-s-solecki/haze/http"
+	"strconv"
+	"strings"
+)
+
+type column struct {
+	name, label string
+	width       int
+	value       func(http.Response) int
+}
+
+var columns = []column{
+	{"code", "Code", 3, func(res http.Response) int { return res.Code }},
+	{"len", "Len", 6, func(res http.Response) int { return int(res.Length) }},
+	{"words", "Words", 5, func(res http.Response) int { return res.Words() }},
+	{"lines", "Lines", 5, func(res http.Response) int { return res.Lines() }},
+	{"time", "Ms", 5, func(res http.Response) int { return int(res.Duration.Milliseconds()) }},
+}
+
+var defaultColumns = []string{"code", "len"}
+
+func findColumn(name string) (column, bool) {
+	for _, c := range columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+func (t *Tui) response(res http.Response) string {
+	names := t.columns
+	if len(names) == 0 {
+		names = defaultColumns
+	}
+	parts := []string{}
+	for i, name := range names {
+		c, _ := findColumn(name)
+		val := strconv.Itoa(c.value(res))
+		if i < len(names)-1 && len(val) < c.width {
+			val += strings.Repeat(" ", c.width-len(val))
+		}
+		if c.name == "code" {
+			val = strings.Replace(val, strconv.Itoa(res.Code), t.code(res), 1)
+		}
+		parts = append(parts, c.label+": "+val)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
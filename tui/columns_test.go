@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"bytes"
+	"github.com/kamil-s-solecki/haze/cliargs"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func sampleResponse(code int, body string) http.Response {
+	return http.Response{Code: code, Length: int64(len(body)), Raw: []byte("HTTP/1.1 200 OK\r\n\r\n" + body)}
+}
+
+func TestColumnValues(t *testing.T) {
+	cases := []struct {
+		body                 string
+		length, words, lines int
+	}{
+		{"", 0, 0, 0},
+		{"foo", 3, 1, 1},
+		{"foo bar\nbaz\n", 12, 3, 2},
+		{"  <p>a  b</p>\n\n\tc", 17, 3, 3},
+	}
+
+	for _, c := range cases {
+		res := sampleResponse(200, c.body)
+		values := map[string]int{}
+		for _, col := range columns {
+			values[col.name] = col.value(res)
+		}
+
+		testutils.AssertEquals(t, values["code"], 200)
+		testutils.AssertEquals(t, values["len"], c.length)
+		testutils.AssertEquals(t, values["words"], c.words)
+		testutils.AssertEquals(t, values["lines"], c.lines)
+	}
+}
+
+func TestDefaultColumns(t *testing.T) {
+	atui := New(&bytes.Buffer{})
+
+	testutils.AssertEquals(t, atui.response(sampleResponse(500, "foo bar")), "[Code: 500, Len: 7]")
+}
+
+func TestSelectedColumnsAreAligned(t *testing.T) {
+	atui := New(&bytes.Buffer{})
+	atui.Configure(cliargs.Args{Columns: "code,len,words,lines"})
+
+	short := atui.response(sampleResponse(500, "foo bar"))
+	long := atui.response(sampleResponse(200, "foo bar baz\nqux\n"))
+
+	testutils.AssertEquals(t, short, "[Code: 500, Len: 7     , Words: 2    , Lines: 1]")
+	testutils.AssertEquals(t, long, "[Code: 200, Len: 16    , Words: 4    , Lines: 2]")
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"fmt"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/mutation"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type result struct {
+	res         http.Response
+	mut         mutation.Mutant
+	diff        string
+	fname       string
+	fingerprint uint64
+}
+
+var sortKeys = map[string]func(http.Response) int64{
+	"code": func(res http.Response) int64 { return int64(res.Code) },
+	"len":  func(res http.Response) int64 { return res.Length },
+	"time": func(res http.Response) int64 { return int64(res.Duration) },
+}
+
+// sortResults sorts in ascending order, keeping the order of arrival for equal values
+func sortResults(rs []result, by string) []result {
+	key, ok := sortKeys[by]
+	if !ok {
+		return rs
+	}
+	sorted := append([]result{}, rs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i].res) < key(sorted[j].res)
+	})
+	return sorted
+}
+
+// groupByCode splits the results by response code, with the codes in ascending order
+func groupByCode(rs []result) [][]result {
+	groups := map[int][]result{}
+	codes := []int{}
+	for _, r := range rs {
+		if _, ok := groups[r.res.Code]; !ok {
+			codes = append(codes, r.res.Code)
+		}
+		groups[r.res.Code] = append(groups[r.res.Code], r)
+	}
+	sort.Ints(codes)
+
+	result := [][]result{}
+	for _, code := range codes {
+		result = append(result, groups[code])
+	}
+	return result
+}
+
+// groupByTag splits the results by payload tag, with the tags in alphabetical order and
+// the untagged results last
+func groupByTag(rs []result) [][]result {
+	groups := map[string][]result{}
+	tags := []string{}
+	for _, r := range rs {
+		if _, ok := groups[r.mut.Tag]; !ok && r.mut.Tag != "" {
+			tags = append(tags, r.mut.Tag)
+		}
+		groups[r.mut.Tag] = append(groups[r.mut.Tag], r)
+	}
+	sort.Strings(tags)
+
+	result := [][]result{}
+	for _, tag := range append(tags, "") {
+		if len(groups[tag]) > 0 {
+			result = append(result, groups[tag])
+		}
+	}
+	return result
+}
+
+// clusterResults groups the results with similar bodies, see http.Response.Fingerprint. Each
+// result joins the first cluster whose first result is at most maxDistance bits away.
+func clusterResults(rs []result, maxDistance int) [][]result {
+	clusters := [][]result{}
+	for _, r := range rs {
+		joined := false
+		for i, cluster := range clusters {
+			if http.Distance(cluster[0].fingerprint, r.fingerprint) <= maxDistance {
+				clusters[i] = append(cluster, r)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			clusters = append(clusters, []result{r})
+		}
+	}
+	return clusters
+}
+
+func (t *Tui) buffered() bool {
+	return t.sortBy != "" || t.grouped || t.byTag || t.cluster
+}
+
+// PrintResults prints the responses held back by -sort, -group-by-code, -group-by-tag or -cluster
+func (t *Tui) PrintResults() {
+	if !t.buffered() {
+		return
+	}
+	t.mu.Lock()
+	rs := sortResults(t.results, t.sortBy)
+	t.mu.Unlock()
+
+	if t.cluster {
+		t.printClusters(rs)
+		return
+	}
+	if !t.grouped && !t.byTag {
+		for _, r := range rs {
+			t.printf("%s", t.crashLine(r))
+		}
+		return
+	}
+
+	groups := groupByCode(rs)
+	if t.byTag {
+		groups = groupByTag(rs)
+	}
+	entries := []entry{}
+	for _, group := range groups {
+		lines := []string{}
+		for _, r := range group {
+			lines = append(lines, fmt.Sprintf("%s %s (%s)", t.response(r.res), t.mutant(r.mut), r.fname))
+		}
+		key := strconv.Itoa(group[0].res.Code)
+		if t.byTag {
+			key = group[0].mut.Tag
+			if key == "" {
+				key = "untagged"
+			}
+		}
+		key += " (" + strconv.Itoa(len(group)) + ")"
+		entries = append(entries, entry{key, strings.Join(lines, "\n")})
+	}
+	if len(entries) > 0 {
+		t.printTable(entries)
+	}
+}
+
+// printClusters prints one representative response per cluster, with the reports of the rest
+func (t *Tui) printClusters(rs []result) {
+	entries := []entry{}
+	for i, cluster := range clusterResults(rs, t.maxDist) {
+		r := cluster[0]
+		line := fmt.Sprintf("%s %s (%s)", t.response(r.res), t.mutant(r.mut), r.fname)
+		if len(cluster) > 1 {
+			similar := []string{}
+			for _, other := range cluster[1:] {
+				similar = append(similar, other.fname)
+			}
+			line += fmt.Sprintf("\n+%d similar: %s", len(similar), strings.Join(similar, ", "))
+		}
+		key := "cluster " + strconv.Itoa(i+1) + " (" + strconv.Itoa(len(cluster)) + ")"
+		entries = append(entries, entry{key, line})
+	}
+	if len(entries) > 0 {
+		t.printTable(entries)
+	}
+}
@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"bytes"
+	"github.com/kamil-s-solecki/haze/cliargs"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/mutation"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleResults() []result {
+	return []result{
+		{res: http.Response{Code: 502, Length: 10, Duration: 30 * time.Millisecond}, fname: "1.md"},
+		{res: http.Response{Code: 500, Length: 30, Duration: 10 * time.Millisecond}, fname: "2.md"},
+		{res: http.Response{Code: 502, Length: 20, Duration: 20 * time.Millisecond}, fname: "3.md"},
+		{res: http.Response{Code: 500, Length: 10, Duration: 40 * time.Millisecond}, fname: "4.md"},
+	}
+}
+
+func fnames(rs []result) string {
+	names := []string{}
+	for _, r := range rs {
+		names = append(names, r.fname)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSortResults(t *testing.T) {
+	rs := sampleResults()
+
+	testutils.AssertEquals(t, fnames(sortResults(rs, "code")), "2.md,4.md,1.md,3.md")
+	testutils.AssertEquals(t, fnames(sortResults(rs, "len")), "1.md,4.md,3.md,2.md")
+	testutils.AssertEquals(t, fnames(sortResults(rs, "time")), "2.md,3.md,1.md,4.md")
+	testutils.AssertEquals(t, fnames(sortResults(rs, "")), "1.md,2.md,3.md,4.md")
+	testutils.AssertEquals(t, fnames(rs), "1.md,2.md,3.md,4.md")
+}
+
+func TestGroupByCode(t *testing.T) {
+	groups := groupByCode(sortResults(sampleResults(), "len"))
+
+	testutils.AssertLen(t, groups, 2)
+	testutils.AssertEquals(t, fnames(groups[0]), "4.md,2.md")
+	testutils.AssertEquals(t, fnames(groups[1]), "1.md,3.md")
+}
+
+func TestGroupedCrashesArePrintedAtTheEnd(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	atui.Configure(cliargs.Args{SortBy: "len", GroupByCode: true})
+
+	for _, r := range sampleResults() {
+		atui.Crash(r.res, mutation.Mutant{Mutation: "SingleQuotes", Mutable: "Path"}, "", r.fname)
+	}
+	testutils.AssertEquals(t, out.String(), "")
+	atui.PrintResults()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	testutils.AssertLen(t, lines, 6)
+	testutils.AssertEquals(t, strings.TrimSpace(lines[1]), "500 (2)         :  [Code: 500, Len: 10] SingleQuotes @ Path (4.md)")
+	testutils.AssertEquals(t, strings.TrimSpace(lines[2]), "[Code: 500, Len: 30] SingleQuotes @ Path (2.md)")
+	testutils.AssertEquals(t, strings.TrimSpace(lines[3]), "502 (2)         :  [Code: 502, Len: 10] SingleQuotes @ Path (1.md)")
+}
+
+func TestGroupByTag(t *testing.T) {
+	rs := sampleResults()
+	rs[0].mut.Tag = "xss"
+	rs[1].mut.Tag = "sqli"
+	rs[3].mut.Tag = "xss"
+
+	groups := groupByTag(rs)
+
+	testutils.AssertLen(t, groups, 3)
+	testutils.AssertEquals(t, fnames(groups[0]), "2.md")
+	testutils.AssertEquals(t, fnames(groups[1]), "1.md,4.md")
+	testutils.AssertEquals(t, fnames(groups[2]), "3.md")
+}
+
+func TestCrashesGroupedByTag(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	atui.Configure(cliargs.Args{GroupByTag: true})
+
+	atui.Crash(http.Response{Code: 500, Length: 10}, mutation.Mutant{Mutation: "Payload", Mutable: "Path", Payload: "'", Tag: "sqli"}, "", "1.md")
+	atui.Crash(http.Response{Code: 502, Length: 10}, mutation.Mutant{Mutation: "SingleQuotes", Mutable: "Path"}, "", "2.md")
+	testutils.AssertEquals(t, out.String(), "")
+	atui.PrintResults()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	testutils.AssertLen(t, lines, 4)
+	testutils.AssertEquals(t, strings.TrimSpace(lines[1]), `sqli (1)        :  [Code: 500, Len: 10] [sqli] Payload "'" @ Path (1.md)`)
+	testutils.AssertEquals(t, strings.TrimSpace(lines[2]), "untagged (1)    :  [Code: 502, Len: 10] SingleQuotes @ Path (2.md)")
+}
+
+func withBody(body string) http.Response {
+	return http.Response{Code: 500, Length: int64(len(body)), Raw: []byte("HTTP/1.1 500 Internal Server Error\r\n\r\n" + body)}
+}
+
+func TestClusterNearDuplicateBodies(t *testing.T) {
+	bodies := []string{
+		"<h1>Internal Server Error</h1><p>Error near 'abc'' at line 1 of your SQL syntax. Request id 12345</p>",
+		"<h1>Not Found</h1><p>The page you requested could not be found on this server, sorry.</p>",
+		"<h1>Internal Server Error</h1><p>Error near 'xyz'' at line 1 of your SQL syntax. Request id 99881</p>",
+		"<h1>Internal Server Error</h1><p>Error near '1-1' at line 1 of your SQL syntax. Request id 31337</p>",
+	}
+	rs := []result{}
+	for i, body := range bodies {
+		res := withBody(body)
+		rs = append(rs, result{res: res, fname: strconv.Itoa(i+1) + ".md", fingerprint: res.Fingerprint()})
+	}
+
+	clusters := clusterResults(rs, 12)
+
+	testutils.AssertLen(t, clusters, 2)
+	testutils.AssertEquals(t, fnames(clusters[0]), "1.md,3.md,4.md")
+	testutils.AssertEquals(t, fnames(clusters[1]), "2.md")
+	testutils.AssertLen(t, clusterResults(rs, 0), 4)
+}
+
+func TestClusteredCrashesPrintOneRepresentative(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	atui.Configure(cliargs.Args{Cluster: true, ClusterDistance: 12})
+	mut := mutation.Mutant{Mutation: "SingleQuotes", Mutable: "Path"}
+
+	atui.Crash(withBody("<p>Error near 'abc'' at line 1 of your SQL syntax. Request id 12345</p>"), mut, "", "1.md")
+	atui.Crash(withBody("<p>Error near 'xyz'' at line 1 of your SQL syntax. Request id 99881</p>"), mut, "", "2.md")
+	testutils.AssertEquals(t, out.String(), "")
+	atui.PrintResults()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	testutils.AssertLen(t, lines, 4)
+	testutils.AssertEquals(t, strings.TrimSpace(lines[1]), "cluster 1 (2)   :  [Code: 500, Len: 71] SingleQuotes @ Path (1.md)")
+	testutils.AssertEquals(t, strings.TrimSpace(lines[2]), "+1 similar: 2.md")
+}
@@ -1,11 +1,13 @@
 package tui
 
 import (
+	"github.com/kamil-s-solecki/haze/utils"
 	"strings"
 )
 
 const (
-	keyLen = 18
+	keyLen      = 18
+	maxValueLen = 100
 )
 
 type entry struct{ key, val string }
@@ -15,15 +17,17 @@ func (t *Tui) printTable(es []entry) {
 	lns := []string{}
 	for _, e := range es {
 		ln := "  " + e.key
-		ln += strings.Repeat(" ", keyLen-len(ln))
+		ln += utils.Padding(ln, keyLen)
 		values := strings.Split(e.val, "\n")
-		ln += ":  " + values[0]
+		ln += ":  " + truncate(values[0])
 		for _, v := range values[1:] {
-			ln += "\n" + strings.Repeat(" ", keyLen) + "   " + v
+			ln += "\n" + strings.Repeat(" ", keyLen) + "   " + truncate(v)
 		}
 		lns = append(lns, ln)
-		if len(ln) > max {
-			max = len(ln)
+		for _, l := range strings.Split(ln, "\n") {
+			if visibleLen(l) > max {
+				max = visibleLen(l)
+			}
 		}
 	}
 
@@ -35,3 +39,11 @@ func (t *Tui) printTable(es []entry) {
 	}
 	t.println(bar)
 }
+
+func truncate(val string) string {
+	if visibleLen(val) <= maxValueLen {
+		return val
+	}
+	runes := []rune(escapeSeq.ReplaceAllString(val, ""))
+	return string(runes[:maxValueLen-1]) + "…"
+}
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"bytes"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"strings"
+	"testing"
+)
+
+func TestVisibleLenIgnoresEscapeSequences(t *testing.T) {
+	cases := []struct {
+		str string
+		len int
+	}{
+		{"500", 3},
+		{red + "500" + reset, 3},
+		{"[Code: " + green + "200" + reset + ", Len: 12]", 20},
+		{"\033[1;31mfoo\033[0m bar", 7},
+	}
+
+	for _, c := range cases {
+		testutils.AssertEquals(t, visibleLen(c.str), c.len)
+	}
+}
+
+func TestTableWidthIgnoresColors(t *testing.T) {
+	plain := &bytes.Buffer{}
+	colored := &bytes.Buffer{}
+	plainTui := New(plain)
+	coloredTui := New(colored)
+	coloredTui.color = true
+
+	plainTui.printTable([]entry{{"5xx", "12"}})
+	coloredTui.printTable([]entry{{"5xx", coloredTui.colorize("12", red)}})
+
+	plainBar := strings.Split(plain.String(), "\n")[0]
+	coloredBar := strings.Split(colored.String(), "\n")[0]
+	testutils.AssertEquals(t, coloredBar, plainBar)
+	testutils.AssertTrue(t, strings.Contains(colored.String(), red+"12"+reset))
+}
+
+func TestColorizeCodes(t *testing.T) {
+	atui := New(&bytes.Buffer{})
+	atui.color = true
+
+	testutils.AssertEquals(t, atui.code(http.Response{Code: 200}), green+"200"+reset)
+	testutils.AssertEquals(t, atui.code(http.Response{Code: 302}), yellow+"302"+reset)
+	testutils.AssertEquals(t, atui.code(http.Response{Code: 404}), "404")
+	testutils.AssertEquals(t, atui.code(http.Response{Code: 503}), red+"503"+reset)
+}
+
+func TestNoColorWhenDisabled(t *testing.T) {
+	atui := New(&bytes.Buffer{})
+
+	testutils.AssertEquals(t, atui.code(http.Response{Code: 500}), "500")
+}
+
+func TestTableHandlesKeyLongerThanKeyLen(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+
+	atui.printTable([]entry{{"A very long table key", "foo"}})
+
+	testutils.AssertTrue(t, strings.Contains(out.String(), "  A very long table key:  foo\n"))
+}
+
+func TestTableTruncatesLongValues(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	long := "http://example.com/" + strings.Repeat("a", 300)
+
+	atui.printTable([]entry{{"Target", long}})
+
+	lns := strings.Split(out.String(), "\n")
+	testutils.AssertEquals(t, visibleLen(lns[1]), keyLen+len(":  ")+maxValueLen)
+	testutils.AssertTrue(t, strings.HasSuffix(lns[1], "aaa…"))
+	testutils.AssertEquals(t, len(lns[0]), visibleLen(lns[1])+2)
+}
@@ -5,44 +5,180 @@ import (
 	"fmt"
 	"github.com/kamil-s-solecki/haze/cliargs"
 	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/mutation"
 	"github.com/kamil-s-solecki/haze/progress"
+	"github.com/kamil-s-solecki/haze/summary"
+	"io"
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Tui struct {
 	buff     *bufio.Writer
 	mu       sync.Mutex
 	errorLog *log.Logger
+	verbose  bool
+	quiet    bool
+	noBanner bool
+	methods  bool
+	errors   bool
+	hosts    bool
+	columns  []string
+	sortBy   string
+	grouped  bool
+	byTag    bool
+	cluster  bool
+	maxDist  int
+	maxBody  int
+	results  []result
+	tty      bool
+	color    bool
 }
 
 func Create() Tui {
+	return New(os.Stdout)
+}
+
+func New(w io.Writer) Tui {
+	tty := isTerminal(w)
 	return Tui{
-		buff:     bufio.NewWriter(os.Stdout),
-		errorLog: log.New(os.Stdout, "ERROR: ", 0),
+		buff:     bufio.NewWriter(w),
+		errorLog: log.New(w, "ERROR: ", 0),
+		tty:      tty,
+		color:    tty && os.Getenv("NO_COLOR") == "",
+	}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (t *Tui) Configure(args cliargs.Args) {
+	t.verbose = args.Verbose
+	t.quiet = args.Quiet
+	t.noBanner = args.NoBanner || args.CountOnly
+	t.methods = args.Methods != ""
+	t.errors = args.ShowErrors
+	t.hosts = args.Host == ""
+	t.sortBy = args.SortBy
+	t.grouped = args.GroupByCode
+	t.byTag = args.GroupByTag
+	t.cluster = args.Cluster
+	t.maxDist = args.ClusterDistance
+	t.maxBody = args.TrimBody
+	if args.Columns != "" {
+		t.columns = strings.Split(args.Columns, ",")
+	}
+	if args.NoColor {
+		t.color = false
 	}
 }
 
 func (t *Tui) FuzzNewFile(rfile string) {
+	if t.quiet {
+		return
+	}
 	t.printf("<< %v >>\n", rfile)
 }
 
 func (t *Tui) FuzzNewRequest(rq http.Request) {
-	t.printf(" * %v %v\n", rq.Method, rq.RequestUri)
+	if t.quiet {
+		return
+	}
+	uri := rq.RequestUri
+	if t.hosts {
+		uri = rq.Target + uri
+	}
+	t.printf(" * %v %v\n", rq.Method, uri)
+}
+
+func (t *Tui) Crash(res http.Response, mut mutation.Mutant, diff, fname string) {
+	r := result{res: res, mut: mut, diff: diff, fname: fname}
+	if t.cluster {
+		r.fingerprint = res.Fingerprint()
+	}
+	if t.buffered() {
+		t.mu.Lock()
+		t.results = append(t.results, r)
+		t.mu.Unlock()
+		return
+	}
+	t.printf("%s", t.crashLine(r))
+}
+
+func (t *Tui) crashLine(r result) string {
+	msg := fmt.Sprintf("(!)  Crash:      %s %s (%s)\n", t.response(r.res), t.mutant(r.mut), r.fname)
+	if t.verbose && r.diff != "" {
+		msg += "                  " + strings.Replace(r.diff, "\n", "\n                  ", -1) + "\n"
+	}
+	return msg
 }
 
-func (t *Tui) Crash(res http.Response, fname string) {
-	t.printf("(!)  Crash:      %s (%s)\n", res, fname)
+func (t *Tui) method(mut mutation.Mutant) string {
+	if !t.methods {
+		return ""
+	}
+	return mut.Method + " "
+}
+
+// mutant describes the mutant of a result, with its tag, and its target when fuzzing many hosts
+func (t *Tui) mutant(mut mutation.Mutant) string {
+	result := ""
+	if mut.Tag != "" {
+		result += "[" + mut.Tag + "] "
+	}
+	if t.hosts && mut.Target != "" {
+		result += mut.Target + " "
+	}
+	return result + t.method(mut) + mut.String()
+}
+
+// RequestError prints the failure of a mutant, with -show-errors along with the mutant and the class of the failure
+func (t *Tui) RequestError(mut mutation.Mutant, err error) {
+	if !t.errors {
+		t.Error(err)
+		return
+	}
+	t.printf("(x)  Error:      [%s] %s: %v\n", summary.ErrorClass(err), mut, err)
+}
+
+func (t *Tui) DryRun(mut mutation.Mutant, raw []byte) {
+	t.printf("---- %s ----\n%s\n\n", mut, raw)
+}
+
+func (t *Tui) Replay(saved []byte, fresh http.Response) {
+	if saved == nil {
+		saved = []byte("(none)")
+	}
+	t.printf("---- Saved response ----\n%s\n\n---- Fresh response ----\n%s\n\n", saved, fresh.TrimmedRaw(t.maxBody))
 }
 
 func (t *Tui) Probe(probe http.Response) {
-	t.printf("     Probe:      %v\n", probe)
+	if t.quiet {
+		return
+	}
+	t.printf("     Probe:      %v\n", t.response(probe))
+}
+
+// Count prints the number of planned mutated requests alone, for -count-only
+func (t *Tui) Count(planned int) {
+	t.printf("%d\n", planned)
 }
 
 func (t *Tui) EmptyLine() {
+	if t.quiet {
+		return
+	}
 	t.printf("\n")
 }
 
@@ -51,29 +187,55 @@ func (t *Tui) Fatal(err error) {
 }
 
 func (t *Tui) Error(err error) {
+	defer t.mu.Unlock()
+	t.mu.Lock()
 	t.errorLog.Println(err)
 }
 
 func (t *Tui) PrintBanner() {
+	if t.quiet || t.noBanner {
+		return
+	}
 	t.println("               .**.        ")
 	t.println("            .. haze ..     ")
 	t.println("               `**`        ")
 }
 
-func (t *Tui) PrintInfo(args cliargs.Args, reportDir string) {
+// PrintInfo prints the settings of the run, with the number of mutated requests it is going to send
+func (t *Tui) PrintInfo(args cliargs.Args, reportDir string, planned int) {
+	if t.quiet {
+		return
+	}
+	target := args.Host
+	if args.TargetsFile != "" {
+		target = "the hosts in " + args.TargetsFile
+	} else if target == "" {
+		target = "the hosts of the har entries"
+	}
 	entries := []entry{
-		{"Target", args.Host},
+		{"Target", target},
 	}
 
 	if !args.ProbeOnly {
 		entries = append(entries, entry{"Report dir", reportDir})
 		entries = append(entries, entry{"Threads", strconv.Itoa(args.Threads)})
+		entries = append(entries, entry{"Requests", strconv.Itoa(planned)})
+	}
+
+	if args.Shuffle || args.ByteOps != "" {
+		entries = append(entries, entry{"Seed", strconv.FormatInt(args.Seed, 10)})
 	}
 
 	if args.Proxy != "" {
 		entries = append(entries, entry{"Proxy", args.Proxy})
 	}
 
+	if args.Http1 {
+		entries = append(entries, entry{"Protocol", "HTTP/1.1"})
+	} else if args.Http2 {
+		entries = append(entries, entry{"Protocol", "HTTP/2"})
+	}
+
 	if args.Cookies != "" {
 		entries = append(entries, entry{"Cookies", args.Cookies})
 	}
@@ -86,11 +248,60 @@ func (t *Tui) PrintInfo(args cliargs.Args, reportDir string) {
 	t.EmptyLine()
 }
 
+func (t *Tui) PrintSummary(s *summary.Summary) {
+	if t.quiet {
+		return
+	}
+	entries := []entry{
+		{"Requests", strconv.Itoa(s.Requests)},
+	}
+	for class := 2; class <= 5; class++ {
+		entries = append(entries, entry{strconv.Itoa(class) + "xx", t.colorize(strconv.Itoa(s.Classes[class]), classColor(class))})
+	}
+	entries = append(entries, entry{"Errors", errorsSummary(s)})
+	entries = append(entries, entry{"Reported", reportedSummary(s)})
+	entries = append(entries, entry{"Elapsed", s.Elapsed().Round(time.Millisecond).String()})
+	if s.Interrupted {
+		entries = append(entries, entry{"Interrupted", "yes, the results are partial"})
+	}
+
+	t.printTable(entries)
+}
+
+func errorsSummary(s *summary.Summary) string {
+	classes := []string{}
+	for _, class := range []string{summary.Timeout, summary.Refused, summary.Reset, summary.Tls, summary.Other} {
+		if n := s.ErrorClasses[class]; n > 0 {
+			classes = append(classes, fmt.Sprintf("%v: %v", class, n))
+		}
+	}
+	if len(classes) == 0 {
+		return strconv.Itoa(s.Errors)
+	}
+	return fmt.Sprintf("%v (%v)", s.Errors, strings.Join(classes, ", "))
+}
+
+func reportedSummary(s *summary.Summary) string {
+	tags := []string{}
+	for tag := range s.Tags {
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return strconv.Itoa(s.Reported)
+	}
+	sort.Strings(tags)
+	for i, tag := range tags {
+		tags[i] = fmt.Sprintf("%v: %v", tag, s.Tags[tag])
+	}
+	return fmt.Sprintf("%v (%v)", s.Reported, strings.Join(tags, ", "))
+}
+
 func (t *Tui) printf(format string, a ...any) {
 	defer t.mu.Unlock()
 	defer t.buff.Flush()
 	t.mu.Lock()
 
+	t.clearLine()
 	fmt.Fprintf(t.buff, format, a...)
 }
 
@@ -99,9 +310,22 @@ func (t *Tui) println(a ...any) {
 	defer t.buff.Flush()
 	t.mu.Lock()
 
+	t.clearLine()
 	fmt.Fprintln(t.buff, a...)
 }
 
+func (t *Tui) clearLine() {
+	if t.tty {
+		fmt.Fprint(t.buff, "\033[0K")
+	}
+}
+
 func (t *Tui) ProgressBar(total int) progress.Bar {
-	return progress.Start(total, t.buff, &t.mu)
+	mode := progress.Plain
+	if t.quiet {
+		mode = progress.Hidden
+	} else if t.tty {
+		mode = progress.Tty
+	}
+	return progress.Start(total, mode, t.buff, &t.mu)
 }
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"github.com/kamil-s-solecki/haze/cliargs"
+	"github.com/kamil-s-solecki/haze/http"
+	"github.com/kamil-s-solecki/haze/mutation"
+	"github.com/kamil-s-solecki/haze/summary"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"strings"
+	"testing"
+)
+
+func TestQuietPrintsNothingDecorative(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	atui.Configure(cliargs.Args{Quiet: true})
+
+	atui.PrintBanner()
+	atui.PrintInfo(cliargs.Args{Host: "http://localhost", Threads: 10}, "/tmp/report", 42)
+	atui.FuzzNewFile("rq.txt")
+	atui.FuzzNewRequest(http.Request{Method: "GET", RequestUri: "/"})
+	atui.Probe(http.Response{Code: 200})
+	bar := atui.ProgressBar(10)
+	for i := 0; i < 10; i++ {
+		bar.Next()
+	}
+	bar.End()
+	atui.PrintSummary(summary.Start())
+
+	testutils.AssertEquals(t, out.String(), "")
+}
+
+func TestQuietStillPrintsCrashes(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	atui.Configure(cliargs.Args{Quiet: true})
+
+	atui.Crash(http.Response{Code: 500, Length: 10}, mutation.Mutant{Mutation: "SingleQuotes", Mutable: "Path"}, "", "1.md")
+
+	testutils.AssertEquals(t, out.String(), "(!)  Crash:      [Code: 500, Len: 10] SingleQuotes @ Path (1.md)\n")
+}
+
+func TestNoBannerPrintsInfo(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	atui.Configure(cliargs.Args{NoBanner: true})
+
+	atui.PrintBanner()
+	testutils.AssertEquals(t, out.String(), "")
+
+	atui.PrintInfo(cliargs.Args{Host: "http://localhost", Threads: 10}, "/tmp/report", 42)
+	testutils.AssertTrue(t, strings.Contains(out.String(), "http://localhost"))
+	testutils.AssertTrue(t, strings.Contains(out.String(), "Requests        :  42"))
+}
+
+func TestCrashShowsMethodWhenFuzzingMethods(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	atui.Configure(cliargs.Args{Methods: "GET,POST"})
+	mut := mutation.Mutant{Request: http.Request{Method: "POST"}, Mutation: "SingleQuotes", Mutable: "Path"}
+
+	atui.Crash(http.Response{Code: 500, Length: 10}, mut, "", "1.md")
+
+	testutils.AssertEquals(t, out.String(), "(!)  Crash:      [Code: 500, Len: 10] POST SingleQuotes @ Path (1.md)\n")
+}
+
+func TestRequestErrorsAreDetailedWithShowErrors(t *testing.T) {
+	mut := mutation.Mutant{Mutation: "SingleQuotes", Mutable: "Path"}
+	err := errors.New("remote error: tls: handshake failure")
+
+	for _, show := range []bool{false, true} {
+		out := &bytes.Buffer{}
+		atui := New(out)
+		atui.Configure(cliargs.Args{ShowErrors: show})
+
+		atui.RequestError(mut, err)
+
+		if show {
+			testutils.AssertEquals(t, out.String(), "(x)  Error:      [tls] SingleQuotes @ Path: remote error: tls: handshake failure\n")
+		} else {
+			testutils.AssertEquals(t, out.String(), "ERROR: remote error: tls: handshake failure\n")
+		}
+	}
+}
+
+func TestSummaryShowsErrorClasses(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	s := summary.Start()
+	s.Add(http.Response{}, errors.New("malformed HTTP response"), false, "")
+	s.Add(http.Response{}, errors.New("remote error: tls: handshake failure"), false, "")
+	s.Add(http.Response{}, errors.New("remote error: tls: bad certificate"), false, "")
+
+	atui.PrintSummary(s)
+
+	testutils.AssertTrue(t, strings.Contains(out.String(), "3 (tls: 2, other: 1)"))
+}
+
+func TestCrashShowsTag(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	mut := mutation.Mutant{Mutation: "SingleQuotes", Mutable: "Path", Tag: "sqli"}
+
+	atui.Crash(http.Response{Code: 500, Length: 10}, mut, "", "1.md")
+
+	testutils.AssertEquals(t, out.String(), "(!)  Crash:      [Code: 500, Len: 10] [sqli] SingleQuotes @ Path (1.md)\n")
+}
+
+func TestSummaryShowsReportedPerTag(t *testing.T) {
+	out := &bytes.Buffer{}
+	atui := New(out)
+	s := summary.Start()
+	s.Add(http.Response{Code: 500}, nil, true, "xss")
+	s.Add(http.Response{Code: 500}, nil, true, "sqli")
+	s.Add(http.Response{Code: 500}, nil, true, "sqli")
+	s.Add(http.Response{Code: 500}, nil, true, "")
+
+	atui.PrintSummary(s)
+
+	testutils.AssertTrue(t, strings.Contains(out.String(), "4 (sqli: 2, xss: 1)"))
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"encoding/base64"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+var base64Charset = regexp.MustCompile(`^[A-Za-z0-9+/_-]+={0,2}$`)
+
+var base64Encodings = []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding}
+
+// DetectBase64 reports whether val is likely base64 encoded data. Short values and values
+// that do not decode to printable text are rejected, since plain words are often valid base64 too.
+func DetectBase64(val string) (*base64.Encoding, []byte, bool) {
+	if len(val) < 8 || !base64Charset.MatchString(val) {
+		return nil, nil, false
+	}
+	for _, enc := range base64Encodings {
+		decoded, err := enc.DecodeString(val)
+		if err == nil && isPrintable(decoded) {
+			return enc, decoded, true
+		}
+	}
+	return nil, nil, false
+}
+
+func isPrintable(bs []byte) bool {
+	if !utf8.Valid(bs) {
+		return false
+	}
+	for _, r := range string(bs) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
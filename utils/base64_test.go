@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"encoding/base64"
+	"github.com/kamil-s-solecki/haze/testutils"
+	"testing"
+)
+
+func TestDetectBase64(t *testing.T) {
+	cases := []struct {
+		val     string
+		enc     *base64.Encoding
+		decoded string
+	}{
+		{"eyJpZCI6MX0=", base64.StdEncoding, `{"id":1}`},
+		{"eyJpZCI6MX0", base64.RawStdEncoding, `{"id":1}`},
+		{"PD8_Pz4-", base64.URLEncoding, "<???>>"},
+	}
+
+	for _, c := range cases {
+		enc, decoded, ok := DetectBase64(c.val)
+
+		testutils.AssertTrue(t, ok)
+		testutils.AssertTrue(t, enc == c.enc)
+		testutils.AssertEquals(t, string(decoded), c.decoded)
+	}
+}
+
+func TestNotDetectBase64(t *testing.T) {
+	for _, val := range []string{"", "abc", "username", "hello world", "a%20b=c"} {
+		_, _, ok := DetectBase64(val)
+
+		testutils.AssertFalse(t, ok)
+	}
+}
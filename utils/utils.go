@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"bufio"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -17,3 +21,44 @@ func UrlEncodeSpecials(val string) string {
 	val = strings.Replace(val, ";", "%3b", -1)
 	return val
 }
+
+// SortedKeys keeps the output, and the order of mutants, stable between runs
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Padding are the spaces aligning the end of s to width, if it is shorter
+func Padding(s string, width int) string {
+	if len(s) >= width {
+		return ""
+	}
+	return strings.Repeat(" ", width-len(s))
+}
+
+// ReadRegexes compiles a regex from each non-empty line of the file
+func ReadRegexes(path string) ([]*regexp.Regexp, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := []*regexp.Regexp{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		r, err := regexp.Compile(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, scanner.Err()
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSortedKeys(t *testing.T) {
+	testutils.AssertEquals(t, strings.Join(SortedKeys(map[string]string{"b": "1", "a": "2", "c": "3"}), ","), "a,b,c")
+	testutils.AssertEquals(t, strings.Join(SortedKeys(map[string]interface{}{"y": 1, "x": nil}), ","), "x,y")
+}
+
+func TestPadding(t *testing.T) {
+	testutils.AssertEquals(t, Padding("  -host", 10), "   ")
+	testutils.AssertEquals(t, Padding("  -a-very-long-option-name", 10), "")
+}
+
+func TestReadRegexes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signatures.txt")
+	os.WriteFile(path, []byte("FooError\n\nBar[0-9]+\n"), 0644)
+
+	got, err := ReadRegexes(path)
+
+	testutils.AssertTrue(t, err == nil)
+	testutils.AssertLen(t, got, 2)
+	testutils.AssertTrue(t, got[1].MatchString("Bar123"))
+}
+
+func TestReadInvalidRegexes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signatures.txt")
+	os.WriteFile(path, []byte("Foo(\n"), 0644)
+
+	_, err := ReadRegexes(path)
+
+	testutils.AssertTrue(t, err != nil)
+}
@@ -0,0 +1,48 @@
+package workerpool
+
+import (
+	"sync/atomic"
+)
+
+type Quota struct {
+	limit   int64
+	used    int64
+	stopped int32
+}
+
+func NewQuota(limit int) *Quota {
+	return &Quota{limit: int64(limit)}
+}
+
+func (q *Quota) Take() bool {
+	if atomic.LoadInt32(&q.stopped) == 1 {
+		return false
+	}
+	if q.limit <= 0 {
+		return true
+	}
+	return atomic.AddInt64(&q.used, 1) <= q.limit
+}
+
+func (q *Quota) Exhausted() bool {
+	return atomic.LoadInt32(&q.stopped) == 1 || q.limit > 0 && atomic.LoadInt64(&q.used) >= q.limit
+}
+
+// Stop exhausts the quota right away. Only the first call returns true.
+func (q *Quota) Stop() bool {
+	return atomic.CompareAndSwapInt32(&q.stopped, 0, 1)
+}
+
+func (q *Quota) Remaining(n int) int {
+	if q.limit <= 0 {
+		return n
+	}
+	left := int(q.limit - atomic.LoadInt64(&q.used))
+	if left < 0 {
+		left = 0
+	}
+	if left < n {
+		return left
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package workerpool
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQuotaCapsSendsWithManyThreads(t *testing.T) {
+	var sent int64
+	quota := NewQuota(100)
+	pool := NewPool(50)
+
+	for i := 0; i < 1000; i++ {
+		pool.RunTask(func() {
+			if quota.Take() {
+				atomic.AddInt64(&sent, 1)
+			}
+		})
+	}
+	pool.Wait()
+
+	testutils.AssertEquals(t, sent, int64(100))
+	testutils.AssertTrue(t, quota.Exhausted())
+}
+
+func TestZeroQuotaIsUnlimited(t *testing.T) {
+	quota := NewQuota(0)
+
+	for i := 0; i < 1000; i++ {
+		testutils.AssertTrue(t, quota.Take())
+	}
+	testutils.AssertFalse(t, quota.Exhausted())
+	testutils.AssertEquals(t, quota.Remaining(7), 7)
+}
+
+func TestQuotaRemaining(t *testing.T) {
+	quota := NewQuota(10)
+	for i := 0; i < 4; i++ {
+		quota.Take()
+	}
+
+	testutils.AssertEquals(t, quota.Remaining(100), 6)
+	testutils.AssertEquals(t, quota.Remaining(3), 3)
+}
+
+func TestStoppedQuotaIsExhausted(t *testing.T) {
+	var first int64
+	quota := NewQuota(0)
+	pool := NewPool(50)
+
+	for i := 0; i < 100; i++ {
+		pool.RunTask(func() {
+			if quota.Stop() {
+				atomic.AddInt64(&first, 1)
+			}
+		})
+	}
+	pool.Wait()
+
+	testutils.AssertEquals(t, first, int64(1))
+	testutils.AssertTrue(t, quota.Exhausted())
+	testutils.AssertFalse(t, quota.Take())
+	testutils.AssertEquals(t, quota.Remaining(7), 7)
+}
@@ -9,20 +9,38 @@ type Pool struct {
 	input chan func()
 }
 
-func worker(input chan func(), wg *sync.WaitGroup) {
+// Slots caps the tasks running at once across several pools
+type Slots chan struct{}
+
+func NewSlots(size int) Slots {
+	return make(Slots, size)
+}
+
+func worker(input chan func(), wg *sync.WaitGroup, slots Slots) {
 	defer wg.Done()
 	for f := range input {
+		if slots != nil {
+			slots <- struct{}{}
+		}
 		f()
+		if slots != nil {
+			<-slots
+		}
 	}
 }
 
 func NewPool(size int) Pool {
+	return NewSharedPool(size, nil)
+}
+
+// NewSharedPool runs at most size tasks at once, and only while one of the slots is free
+func NewSharedPool(size int, slots Slots) Pool {
 	wg := new(sync.WaitGroup)
 	input := make(chan func())
 
 	for i := 0; i < size; i++ {
 		wg.Add(1)
-		go worker(input, wg)
+		go worker(input, wg, slots)
 	}
 
 	return Pool{wg, input}
@@ -0,0 +1,30 @@
+package workerpool
+
+import (
+	"github.com/kamil-s-solecki/haze/testutils"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSharedPoolsRunAtMostTheirSlots(t *testing.T) {
+	var running, peak int64
+	slots := NewSlots(3)
+	pools := []Pool{NewSharedPool(2, slots), NewSharedPool(2, slots)}
+
+	for i := 0; i < 20; i++ {
+		pools[i%2].RunTask(func() {
+			n := atomic.AddInt64(&running, 1)
+			for max := atomic.LoadInt64(&peak); n > max; max = atomic.LoadInt64(&peak) {
+				atomic.CompareAndSwapInt64(&peak, max, n)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&running, -1)
+		})
+	}
+	for _, p := range pools {
+		p.Wait()
+	}
+
+	testutils.AssertTrue(t, atomic.LoadInt64(&peak) <= 3)
+}